@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestAnalyzeBrokenRepoConfig(t *testing.T) {
+	var paths []string
+	for _, config := range []string{"jira_project: BILL\n", "jira_project: [BILL\n"} {
+		dir := t.TempDir()
+		repo, err := git.PlainInit(dir, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wt, err := repo.Worktree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "main.txt"), []byte("line\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("main.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Commit("Add main.txt", &git.CommitOptions{
+			Author: &object.Signature{Name: "Al", Email: "al@example.com", When: time.Now()},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, repoConfigFile), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, dir)
+	}
+
+	failed := reposFailed.Load()
+	directories := analyze(paths, options{window: time.Hour}, nil)
+	if len(directories) != 1 || directories[0].path != paths[0] {
+		t.Errorf("analyze returned %d directories, want only %s", len(directories), paths[0])
+	}
+	if got := reposFailed.Load() - failed; got != 1 {
+		t.Errorf("analyze counted %d failed repos, want 1", got)
+	}
+}
+
+func TestAddPreviousCountsNothing(t *testing.T) {
+	paths := []string{t.TempDir()} // not a repo
+	opened, failed := reposOpened.Load(), reposFailed.Load()
+	addPrevious(nil, paths, options{window: time.Hour})
+	if reposOpened.Load() != opened || reposFailed.Load() != failed {
+		t.Errorf("addPrevious counted repos: opened %d, failed %d, want %d, %d",
+			reposOpened.Load(), reposFailed.Load(), opened, failed)
+	}
+}
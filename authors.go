@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// stripMarks decomposes text, drops its diacritics and composes it back.
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// authorKey returns name as authors are compared: without diacritics, case
+// folded and with single spaces, so "Jozef Reisinger", "jozef reisinger" and
+// "Jožef Reisinger" are the same author.
+func authorKey(name string) string {
+	s, _, err := transform.String(stripMarks, name)
+	if err != nil {
+		s = norm.NFC.String(name)
+	}
+	return cases.Fold().String(strings.Join(strings.Fields(s), " "))
+}
+
+// sameAuthor tells whether names a and b are of the same author.
+func sameAuthor(a, b string) bool {
+	return a == b || authorKey(a) == authorKey(b)
+}
+
+// lookupAuthor returns the value of author in m, keyed by names of authors
+// as written in config.
+func lookupAuthor(m map[string]string, author string) (string, bool) {
+	if v, ok := m[author]; ok {
+		return v, true
+	}
+	for name, v := range m {
+		if sameAuthor(name, author) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// authorChanges returns changes per author in dir. If path is not empty
+// only changes of that file are counted.
+func (dir directory) authorChanges(path string) map[string]int {
+	perAuthor := make(map[string]int)
+	for _, c := range dir.commits {
+		for _, f := range c.files {
+			if path == "" || f.path == path {
+				perAuthor[c.author] += f.changes
+			}
+		}
+	}
+	return perAuthor
+}
+
+// authorNames returns authors of changes in dir, most changes first. If path
+// is not empty only authors of that file are returned.
+func (dir directory) authorNames(path string) []string {
+	names := []string{}
+	for _, s := range shares(dir.authorChanges(path)) {
+		names = append(names, s.author)
+	}
+	return names
+}
+
+// netChanges returns added minus deleted lines in dir. If path is not empty
+// only lines of that file are counted.
+func (dir directory) netChanges(path string) int {
+	var net int
+	for _, c := range dir.commits {
+		for _, f := range c.files {
+			if path == "" || f.path == path {
+				net += f.net
+			}
+		}
+	}
+	return net
+}
+
+// formatAuthors returns authors ordered by changes. If there are more than
+// max authors only the top max are listed followed by "+N more"; max < 1
+// lists all.
+func formatAuthors(perAuthor map[string]int, max int) string {
+	var names []string
+	for _, s := range shares(perAuthor) {
+		names = append(names, s.author)
+	}
+	if max > 0 && len(names) > max {
+		more := len(names) - max
+		names = append(names[:max], fmt.Sprintf("+%d more", more))
+	}
+	return strings.Join(names, ", ")
+}
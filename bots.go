@@ -0,0 +1,17 @@
+package main
+
+import "regexp"
+
+var (
+	// botName matches names of bots committing to repos, like
+	// dependabot[bot] or Renovate Bot.
+	botName = regexp.MustCompile(`(?i)\[bot\]|^(dependabot|renovate|greenkeeper|snyk|github-actions|pre-commit-ci|imgbot|allcontributors)\b|\bbot\b`)
+	// botEmail matches emails of bots, like those of GitHub Apps.
+	botEmail = regexp.MustCompile(`(?i)\[bot\]@users\.noreply\.github\.com$|^bot@renovateapp\.com$|^support@dependabot\.com$|@bots?\.`)
+)
+
+// isBot tells whether commit c was made by a bot, by its author. Subjects
+// don't tell, as people write "Bump version from 1.2 to 1.3" too.
+func isBot(c commitInfo) bool {
+	return botName.MatchString(c.author) || botEmail.MatchString(c.email)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxBar is the width of the longest histogram bar.
+const maxBar = 40
+
+// reportBusiest prints histograms of commits by weekday and by hour of day
+// in the -tz timezone.
+func reportBusiest(w io.Writer, directories []directory) {
+	var weekdays [7]int
+	var hours [24]int
+	for _, dir := range directories {
+		for _, c := range dir.commits {
+			t := c.when.In(loc)
+			weekdays[t.Weekday()]++
+			hours[t.Hour()]++
+		}
+	}
+
+	line := func(label string, n int, counts []int) {
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%-7s  %7d  %s", label, n, bar(n, counts)), " "))
+	}
+
+	fmt.Fprintf(w, "%-7s  %7s\n", "WEEKDAY", "COMMITS")
+	for i := 0; i < 7; i++ {
+		d := (time.Monday + time.Weekday(i)) % 7
+		line(d.String()[:3], weekdays[d], weekdays[:])
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%-7s  %7s\n", "HOUR", "COMMITS")
+	for h, n := range hours {
+		line(fmt.Sprintf("%02d", h), n, hours[:])
+	}
+}
+
+// bar returns a bar proportional to n relative to the largest of counts.
+func bar(n int, counts []int) string {
+	var max int
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	return scaledBar(n, max)
+}
+
+// scaledBar returns a bar proportional to n relative to max.
+func scaledBar(n, max int) string {
+	if max == 0 {
+		return ""
+	}
+	return strings.Repeat("#", n*maxBar/max)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workCategories are what changed files are, in the order shown.
+var workCategories = []string{"source", "tests", "docs", "config", "build"}
+
+var (
+	// testDirs hold tests, fixtures and test data.
+	testDirs = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true, "testdata": true}
+	// testSuffixes end names of test files after their base name, matching
+	// case so latest.java isn't one.
+	testSuffixes = []string{"_test.go", "_test.py", "_spec.rb", "_test.rb", "Test.java", "Tests.java", "Test.kt", "Tests.cs"}
+
+	docDirs = map[string]bool{"doc": true, "docs": true, "documentation": true, "man": true}
+	docExts = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".txt": true, ".1": true}
+	// docNames are base names without extension of documentation files.
+	docNames = map[string]bool{"readme": true, "changelog": true, "changes": true, "license": true,
+		"copying": true, "notice": true, "authors": true, "contributing": true, "code_of_conduct": true}
+
+	buildNames = map[string]bool{"makefile": true, "gnumakefile": true, "cmakelists.txt": true, "go.mod": true,
+		"go.sum": true, "go.work": true, "package.json": true, "package-lock.json": true, "yarn.lock": true,
+		"pnpm-lock.yaml": true, "cargo.toml": true, "cargo.lock": true, "pom.xml": true, "build.gradle": true,
+		"build.gradle.kts": true, "settings.gradle": true, "setup.py": true, "setup.cfg": true,
+		"pyproject.toml": true, "pipfile": true, "pipfile.lock": true, "poetry.lock": true, "gemfile": true,
+		"gemfile.lock": true, "build": true, "build.bazel": true, "workspace": true, "meson.build": true,
+		"configure.ac": true, "justfile": true, "taskfile.yml": true}
+	buildExts = map[string]bool{".mk": true, ".cmake": true, ".bzl": true, ".gradle": true, ".csproj": true, ".sln": true}
+
+	// configDirs hold CI, deployment and infrastructure.
+	configDirs = map[string]bool{".github": true, ".circleci": true, ".gitlab": true, "deploy": true,
+		"k8s": true, "kubernetes": true, "helm": true, "charts": true, "terraform": true, "ansible": true, "infra": true}
+	configNames = map[string]bool{"jenkinsfile": true, "vagrantfile": true, "procfile": true}
+	configExts  = map[string]bool{".yml": true, ".yaml": true, ".json": true, ".toml": true, ".ini": true,
+		".cfg": true, ".conf": true, ".env": true, ".properties": true, ".tf": true, ".tfvars": true, ".hcl": true}
+)
+
+// fileCategory returns what the file at p is by its path: tests, build,
+// config, docs or, if none of them, source.
+func fileCategory(p string) string {
+	if isTestFile(p) {
+		return "tests"
+	}
+	p = strings.ToLower(slashPath(p))
+	base := path.Base(p)
+	ext := path.Ext(base)
+	dirs := strings.Split(path.Dir(p), "/")
+	inDir := func(names map[string]bool) bool {
+		for _, d := range dirs {
+			if names[d] {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case buildNames[base] || buildExts[ext] || strings.HasPrefix(base, "requirements") && ext == ".txt":
+		return "build"
+	case inDir(configDirs) || configNames[base] || configExts[ext] ||
+		strings.HasPrefix(base, "dockerfile") || strings.HasPrefix(base, "docker-compose") ||
+		strings.HasPrefix(base, ".") && ext == base:
+		return "config"
+	case inDir(docDirs) || docExts[ext] || docNames[strings.TrimSuffix(base, ext)]:
+		return "docs"
+	}
+	return "source"
+}
+
+// isTestFile tells whether the file at p holds tests or their data, by
+// built-in heuristics or test patterns of config.
+func isTestFile(p string) bool {
+	if conf.TestPatterns.match(p) {
+		return true
+	}
+	p = slashPath(p)
+	base := path.Base(p)
+	for _, s := range testSuffixes {
+		if strings.HasSuffix(base, s) && base != s {
+			return true
+		}
+	}
+	p, base = strings.ToLower(p), strings.ToLower(base)
+	for _, d := range strings.Split(path.Dir(p), "/") {
+		if testDirs[d] {
+			return true
+		}
+	}
+	// test_x.py, x.test.js, x.spec.ts
+	return strings.HasPrefix(base, "test_") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.")
+}
+
+// testPatterns are patterns of paths of test files. Patterns with a slash
+// match the whole path or its leading directories, others the file name or
+// any directory name.
+type testPatterns []string
+
+func (t *testPatterns) UnmarshalYAML(n *yaml.Node) error {
+	var patterns []string
+	if err := n.Decode(&patterns); err != nil {
+		return err
+	}
+	for _, p := range patterns {
+		p = foldPath(slashPath(p))
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("line %d: test_patterns: %q: %v", n.Line, p, err)
+		}
+		*t = append(*t, p)
+	}
+	return nil
+}
+
+func (t testPatterns) match(file string) bool {
+	file = foldPath(slashPath(file))
+	parts := strings.Split(file, "/")
+	for _, p := range t {
+		for i := range parts {
+			name := parts[i]
+			if strings.Contains(p, "/") {
+				name = strings.Join(parts[:i+1], "/")
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// testChurn returns changes of test files and of other source code in dir.
+func (dir directory) testChurn() (tests, code int) {
+	for _, f := range dir.files {
+		switch fileCategory(f.path) {
+		case "tests":
+			tests += f.changes
+		case "source":
+			code += f.changes
+		}
+	}
+	return tests, code
+}
+
+// testRatioCell returns changes of tests per change of code.
+func testRatioCell(tests, code int) string {
+	if code == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", float64(tests)/float64(code))
+}
+
+// reportCategories prints the share of changes in each category of files per
+// repo and in total, like "this week was 40% tests".
+func reportCategories(w io.Writer, directories []directory) {
+	if len(directories) == 0 {
+		return
+	}
+	total := make(map[string]int)
+	var totalChanges int
+	row := func(path string, perCategory map[string]int, changes int) []string {
+		cells := []string{path}
+		for _, c := range workCategories {
+			cells = append(cells, percent(perCategory[c], changes))
+		}
+		return cells
+	}
+
+	sortDirectories(directories)
+	var rows [][]string
+	for _, dir := range directories {
+		perCategory := make(map[string]int)
+		var changes int
+		for _, f := range dir.files {
+			c := fileCategory(f.path)
+			perCategory[c] += f.changes
+			total[c] += f.changes
+			changes += f.changes
+		}
+		totalChanges += changes
+		rows = append(rows, row(displayPath(dir.path), perCategory, changes))
+	}
+	rows = append(rows, row("TOTAL", total, totalChanges))
+
+	tw := newTableWriter(w)
+	header := []string{"PATH"}
+	for _, c := range workCategories {
+		header = append(header, strings.ToUpper(c))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// commitTypes are the conventional commit types reported separately. Other
+// types and commits not following the convention are reported as other.
+var commitTypes = []string{"feat", "fix", "refactor", "test", "docs", "chore"}
+
+// conventionalCommit matches the type prefix of a conventional commit subject
+// like "feat(parser)!: ...".
+var conventionalCommit = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?!?:`)
+
+// commitType returns the conventional commit type of subject.
+func commitType(subject string) string {
+	m := conventionalCommit.FindStringSubmatch(subject)
+	if m == nil {
+		return "other"
+	}
+	t := strings.ToLower(m[1])
+	for _, known := range commitTypes {
+		if t == known {
+			return t
+		}
+	}
+	return "other"
+}
+
+// reportTypes prints the share of changes per conventional commit type for
+// each repo and overall.
+func reportTypes(w io.Writer, directories []directory) {
+	if len(directories) == 0 {
+		return
+	}
+	columns := append(append([]string{}, commitTypes...), "other")
+
+	total := make(map[string]int)
+	var totalChanges int
+	row := func(path string, perType map[string]int, changes int) []string {
+		cells := []string{path}
+		for _, t := range columns {
+			cells = append(cells, percent(perType[t], changes))
+		}
+		return cells
+	}
+
+	sortDirectories(directories)
+	var rows [][]string
+	for _, dir := range directories {
+		perType := make(map[string]int)
+		for _, c := range dir.commits {
+			t := commitType(c.subject)
+			perType[t] += c.changes
+			total[t] += c.changes
+		}
+		totalChanges += dir.changes
+		rows = append(rows, row(displayPath(dir.path), perType, dir.changes))
+	}
+	rows = append(rows, row("TOTAL", total, totalChanges))
+
+	tw := newTableWriter(w)
+	header := []string{"PATH"}
+	for _, t := range columns {
+		header = append(header, strings.ToUpper(t))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	tw.Flush()
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.*f%%", *percentPrecision, float64(n)/float64(total)*100)
+}
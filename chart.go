@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Chart layout in pixels.
+const (
+	chartWidth   = 800
+	chartLabelW  = 220 // repo names left of the bars
+	chartRowH    = 20
+	chartPadding = 10
+)
+
+// chartColors are the colors of authors in the stacked chart, the last one
+// is for the rest of them.
+var chartColors = []color.RGBA{
+	{0x4e, 0x79, 0xa7, 0xff}, {0xf2, 0x8e, 0x2b, 0xff}, {0xe1, 0x57, 0x59, 0xff},
+	{0x76, 0xb7, 0xb2, 0xff}, {0x59, 0xa1, 0x4f, 0xff}, {0xed, 0xc9, 0x48, 0xff},
+	{0xb0, 0x7a, 0xa1, 0xff}, {0xba, 0xb0, 0xac, 0xff},
+}
+
+// canvas is what charts are drawn on.
+type canvas interface {
+	rect(x, y, w, h int, c color.RGBA)
+	text(x, y int, s string) // y is the baseline
+}
+
+// writeChart writes a bar chart of changes per repo followed by a chart of
+// changes per repo stacked by author to file. Its format, PNG or SVG, is
+// given by the file extension.
+func writeChart(file string, directories []directory) error {
+	sortDirectories(directories)
+	var max int
+	for _, dir := range directories {
+		if dir.changes > max {
+			max = dir.changes
+		}
+	}
+
+	// Top authors get their own color, the rest share the last one.
+	all := make(map[string]int)
+	for _, dir := range directories {
+		for a, n := range dir.authorChanges("") {
+			all[a] += n
+		}
+	}
+	colorOf := make(map[string]color.RGBA)
+	var legend []string
+	for i, s := range shares(all) {
+		if i < len(chartColors)-1 {
+			colorOf[s.author] = chartColors[i]
+			legend = append(legend, s.author)
+		}
+	}
+	if len(all) > len(chartColors)-1 {
+		legend = append(legend, "others")
+	}
+	authorColor := func(a string) color.RGBA {
+		if c, ok := colorOf[a]; ok {
+			return c
+		}
+		return chartColors[len(chartColors)-1]
+	}
+
+	rows := len(directories)
+	height := chartPadding + (rows+1)*chartRowH + chartPadding + (rows+1)*chartRowH + chartPadding + len(legend)*chartRowH + chartPadding
+
+	var c canvas
+	var finish func() error
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".png":
+		img := image.NewRGBA(image.Rect(0, 0, chartWidth, height))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		c = pngCanvas{img}
+		finish = func() error {
+			f, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			if err := png.Encode(f, img); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+	case ".svg":
+		s := &svgCanvas{}
+		fmt.Fprintf(&s.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n", chartWidth, height)
+		fmt.Fprintf(&s.b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+		c = s
+		finish = func() error {
+			s.b.WriteString("</svg>\n")
+			return os.WriteFile(file, []byte(s.b.String()), 0644)
+		}
+	default:
+		return fmt.Errorf("want a .png or .svg file, got %s", file)
+	}
+
+	barW := func(changes int) int {
+		if max == 0 {
+			return 0
+		}
+		return changes * (chartWidth - chartLabelW - 2*chartPadding - 60) / max
+	}
+	label := func(s string) string { return truncateLeft(s, (chartLabelW-chartPadding)/7) }
+
+	y := chartPadding
+	c.text(chartPadding, y+14, "Changes per repo")
+	y += chartRowH
+	for _, dir := range directories {
+		w := barW(dir.changes)
+		c.text(chartPadding, y+14, label(displayPath(dir.path)))
+		c.rect(chartLabelW, y+3, w, chartRowH-6, chartColors[0])
+		c.text(chartLabelW+w+5, y+14, fmt.Sprint(dir.changes))
+		y += chartRowH
+	}
+
+	y += chartPadding
+	c.text(chartPadding, y+14, "Changes per repo and author")
+	y += chartRowH
+	for _, dir := range directories {
+		c.text(chartPadding, y+14, label(displayPath(dir.path)))
+		var sum int
+		for _, s := range shares(dir.authorChanges("")) {
+			// Segments end where the sum so far does, so rounding doesn't
+			// add up.
+			x := chartLabelW + barW(sum)
+			sum += s.changes
+			c.rect(x, y+3, chartLabelW+barW(sum)-x, chartRowH-6, authorColor(s.author))
+		}
+		y += chartRowH
+	}
+
+	y += chartPadding
+	for _, a := range legend {
+		c.rect(chartPadding, y+4, 12, 12, authorColor(a))
+		c.text(chartPadding+18, y+14, a)
+		y += chartRowH
+	}
+	return finish()
+}
+
+// pngCanvas draws on an image with a fixed-size bitmap font.
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func (p pngCanvas) rect(x, y, w, h int, c color.RGBA) {
+	draw.Draw(p.img, image.Rect(x, y, x+w, y+h), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (p pngCanvas) text(x, y int, s string) {
+	d := font.Drawer{
+		Dst:  p.img,
+		Src:  image.Black,
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+// svgCanvas collects SVG elements.
+type svgCanvas struct {
+	b strings.Builder
+}
+
+func (s *svgCanvas) rect(x, y, w, h int, c color.RGBA) {
+	fmt.Fprintf(&s.b, `<rect x="%d" y="%d" width="%d" height="%d" fill="#%02x%02x%02x"/>`+"\n", x, y, w, h, c.R, c.G, c.B)
+}
+
+func (s *svgCanvas) text(x, y int, t string) {
+	fmt.Fprintf(&s.b, `<text x="%d" y="%d">%s</text>`+"\n", x, y, html.EscapeString(t))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+)
+
+// colorOn is set by -color.
+var colorOn bool
+
+// ANSI escape sequences.
+const (
+	reset = "\x1b[0m"
+	bold  = "\x1b[1m"
+	dim   = "\x1b[2m"
+)
+
+// authorColors are foreground colors that author names are assigned to.
+var authorColors = []string{"\x1b[31m", "\x1b[32m", "\x1b[33m", "\x1b[34m", "\x1b[35m", "\x1b[36m"}
+
+// useColor tells whether -color is set to "always", or to "auto" and
+// stdout is a terminal and NO_COLOR is not set.
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "", nil
+	}
+	return false, fmt.Errorf("want always, never or auto, got %q", mode)
+}
+
+// isTerminal tells whether f is a character device like a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorAuthor colors name so that it's always the same color.
+func colorAuthor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return authorColors[h.Sum32()%uint32(len(authorColors))] + name + reset
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// reportComparison prints changes and commits of each of authors per repo
+// side by side. Repos none of them committed to are left out.
+func reportComparison(w io.Writer, directories []directory, authors []string) {
+	t := table{header: []string{"PATH"}, authorsCol: -1}
+	for _, a := range authors {
+		t.header = append(t.header, a+" CHANGES", "COMMITS")
+	}
+
+	changes := make([]int, len(authors))
+	commits := make([]int, len(authors))
+	sortDirectories(directories)
+	for _, dir := range directories {
+		perAuthor := dir.authorChanges("")
+		row := []string{displayPath(dir.path)}
+		var any bool
+		for i, a := range authors {
+			n := dir.countCommits(func(c commitInfo) bool { return sameAuthor(c.author, a) })
+			var authored int
+			for name, c := range perAuthor {
+				if sameAuthor(name, a) {
+					authored += c
+				}
+			}
+			row = append(row, fmt.Sprint(authored), fmt.Sprint(n))
+			changes[i] += authored
+			commits[i] += n
+			any = any || n > 0
+		}
+		if any {
+			t.add("", row...)
+		}
+	}
+
+	row := []string{"TOTAL"}
+	for i := range authors {
+		row = append(row, fmt.Sprint(changes[i]), fmt.Sprint(commits[i]))
+	}
+	t.add(bold, row...)
+	t.write(w)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config holds settings that don't fit on the command line.
+type config struct {
+	SMTP     smtpConfig     `yaml:"smtp"`
+	Toggl    togglConfig    `yaml:"toggl"`
+	Clockify clockifyConfig `yaml:"clockify"`
+	Jira     jiraConfig     `yaml:"jira"`
+	Harvest  harvestConfig  `yaml:"harvest"`
+	GitHub   githubConfig   `yaml:"github"`
+	// GitLab and Gerrit hold settings of servers by host.
+	GitLab  map[string]gitlabConfig `yaml:"gitlab"`
+	Gerrit  map[string]gerritConfig `yaml:"gerrit"`
+	Invoice invoiceConfig           `yaml:"invoice"`
+	Narrate narrateConfig           `yaml:"narrate"`
+	// Tickets are regular expressions matching ticket references in
+	// commit messages.
+	Tickets []string `yaml:"tickets"`
+	// TestPatterns match test files besides the built-in heuristics.
+	TestPatterns testPatterns `yaml:"test_patterns"`
+	// Weights of changes by file pattern for the score.
+	Weights weights `yaml:"weights"`
+	// OptOutMarker in a commit message leaves the commit out of reports.
+	// Nil means the default "[no-stats]", empty disables it.
+	OptOutMarker *string `yaml:"opt_out_marker"`
+	// Names of repos by path, remote URL or directory name shown instead
+	// of their paths.
+	Names map[string]string `yaml:"names"`
+	// Codenames of repos by path or directory name shown with -redact.
+	Codenames map[string]string `yaml:"codenames"`
+	// APILimits are rate limits of API hosts, like api.github.com, other
+	// hosts get defaultAPILimit.
+	APILimits map[string]apiLimit `yaml:"api_limits"`
+}
+
+// optOutMarker returns the marker opting commits out of reports.
+func (c config) optOutMarker() string {
+	if c.OptOutMarker == nil {
+		return "[no-stats]"
+	}
+	return *c.OptOutMarker
+}
+
+type smtpConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+	TLS      string `yaml:"tls"` // starttls (default), tls or none
+}
+
+type togglConfig struct {
+	Token       string `yaml:"token"`
+	WorkspaceID int    `yaml:"workspace_id"`
+	ProjectID   int    `yaml:"project_id"`
+}
+
+type clockifyConfig struct {
+	APIKey      string `yaml:"api_key"`
+	WorkspaceID string `yaml:"workspace_id"`
+	ProjectID   string `yaml:"project_id"`
+}
+
+type jiraConfig struct {
+	URL   string `yaml:"url"`
+	User  string `yaml:"user"` // Jira Cloud account email, empty for a PAT
+	Token string `yaml:"token"`
+	// Projects are keys of projects whose issues commits mention.
+	Projects []string `yaml:"projects"`
+}
+
+type harvestConfig struct {
+	Token     string `yaml:"token"`
+	AccountID int    `yaml:"account_id"`
+	// Projects maps repo paths or directory names to Harvest projects.
+	Projects map[string]harvestProject `yaml:"projects"`
+}
+
+type githubConfig struct {
+	Token string `yaml:"token"` // default $GITHUB_TOKEN
+	// URL of a GitHub Enterprise server, like https://github.example.com.
+	URL string `yaml:"url"`
+	// Logins are GitHub logins by -author.
+	Logins map[string]string `yaml:"logins"`
+}
+
+type gitlabConfig struct {
+	Token string `yaml:"token"`
+	// URL of the server, default https:// followed by its host.
+	URL string `yaml:"url"`
+	// Usernames are GitLab usernames by -author.
+	Usernames map[string]string `yaml:"usernames"`
+}
+
+type gerritConfig struct {
+	// URL of the server, default https:// followed by its host.
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"` // HTTP password
+	// Accounts are Gerrit usernames or emails by -author, which otherwise
+	// matches names too.
+	Accounts map[string]string `yaml:"accounts"`
+}
+
+type narrateConfig struct {
+	// URL of an OpenAI compatible chat completions endpoint, default
+	// of a local Ollama.
+	URL   string `yaml:"url"`
+	Model string `yaml:"model"`
+	Token string `yaml:"token"`
+	// Exclude are patterns of paths of files not sent.
+	Exclude []string `yaml:"exclude"`
+	// Redact are regular expressions of text replaced before sending.
+	Redact []string `yaml:"redact"`
+}
+
+type harvestProject struct {
+	ProjectID int `yaml:"project_id"`
+	TaskID    int `yaml:"task_id"`
+}
+
+type invoiceConfig struct {
+	From        string  `yaml:"from"`
+	To          string  `yaml:"to"`
+	Currency    string  `yaml:"currency"`
+	DefaultRate float64 `yaml:"default_rate"`
+	// Rates maps repo paths or directory names to hourly rates.
+	Rates map[string]float64 `yaml:"rates"`
+}
+
+// conf is the loaded config.
+var conf config
+
+// defaultConfigFile returns the path of the config file used when -config is
+// not set.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "workedon", "config.yaml")
+}
+
+// configDir returns the directory of the config file at path, or of the
+// default config file if path is empty.
+func configDir(path string) string {
+	if path == "" {
+		path = defaultConfigFile()
+	}
+	if path == "" {
+		return ""
+	}
+	return filepath.Dir(path)
+}
+
+// loadConfig reads config from path. A missing default config file is not an
+// error.
+func loadConfig(path string) (config, error) {
+	var c config
+
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile()
+		if path == "" {
+			return c, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// maxChangeset is the most files a commit may change to be considered
+	// for coupling; bulk changes like renames or reformatting couple
+	// everything with everything.
+	maxChangeset = 30
+	// minShared is how many commits two files must share to be reported.
+	minShared = 2
+	// maxCouplings is how many couplings are reported per repo.
+	maxCouplings = 10
+)
+
+type couple struct {
+	a, b   string
+	shared int
+	degree float64 // shared commits divided by average commits of a and b
+}
+
+// couplings returns pairs of files in dir changed together in at least
+// minShared commits, strongest first.
+func couplings(dir directory) []couple {
+	revs := make(map[string]int)
+	type pair struct{ a, b string }
+	shared := make(map[pair]int)
+	for _, c := range dir.commits {
+		if len(c.files) > maxChangeset {
+			continue
+		}
+		var files []string
+		for _, f := range c.files {
+			files = append(files, f.path)
+		}
+		files = uniq(files)
+		sort.Strings(files)
+		for i, a := range files {
+			revs[a]++
+			for _, b := range files[i+1:] {
+				shared[pair{a, b}]++
+			}
+		}
+	}
+
+	var couples []couple
+	for p, n := range shared {
+		if n < minShared {
+			continue
+		}
+		avg := float64(revs[p.a]+revs[p.b]) / 2
+		couples = append(couples, couple{a: p.a, b: p.b, shared: n, degree: float64(n) / avg})
+	}
+	sort.Slice(couples, func(i, j int) bool {
+		if couples[i].degree != couples[j].degree {
+			return couples[i].degree > couples[j].degree
+		}
+		if couples[i].shared != couples[j].shared {
+			return couples[i].shared > couples[j].shared
+		}
+		return couples[i].a+couples[i].b < couples[j].a+couples[j].b
+	})
+	if len(couples) > maxCouplings {
+		couples = couples[:maxCouplings]
+	}
+	return couples
+}
+
+// reportCoupling prints the strongest change couplings of each repo.
+func reportCoupling(w io.Writer, directories []directory) {
+	sort.Slice(directories, func(i, j int) bool { return directories[i].path < directories[j].path })
+
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	var header bool
+	for _, dir := range directories {
+		for _, c := range couplings(dir) {
+			if !header {
+				fmt.Fprintf(tw, format, "REPO", "FILE", "COUPLED WITH", "SHARED", "DEGREE")
+				header = true
+			}
+			fmt.Fprintf(tw, format, displayPath(dir.path), c.a, c.b, c.shared, fmt.Sprintf("%.0f%%", c.degree*100))
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// daemon scans repos at paths every interval, reports the results and
+// publishes them. It never returns.
+func daemon(interval time.Duration, paths []string, opts options) {
+	if interval <= 0 {
+		log.Fatalf("-every: want positive interval, got %v", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		directories := scan(paths, opts)
+		if pullFailed() != nil {
+			os.Exit(exitPull)
+		}
+		scanDuration := time.Since(start)
+
+		log.Printf("scanned %d repos in %v", len(paths), scanDuration.Round(time.Millisecond))
+		reportResults(os.Stdout, directories)
+		publish(directories, opts, scanDuration)
+
+		<-ticker.C
+	}
+}
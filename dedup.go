@@ -0,0 +1,50 @@
+package main
+
+import "sort"
+
+// dedupe drops commits already seen in another directory, so that mirrors,
+// forks and vendored copies of a repo are counted once. Directories are
+// taken in path order, the first one keeps the commits. Directories left
+// without commits are dropped.
+func dedupe(directories []directory) []directory {
+	sort.Sort(byDirPath(directories))
+	seen := make(map[string]bool)
+	var deduped []directory
+	for _, dir := range directories {
+		var commits []commitInfo
+		for _, c := range dir.commits {
+			if !seen[c.hash] {
+				seen[c.hash] = true
+				commits = append(commits, c)
+			}
+		}
+		if len(commits) == 0 {
+			continue
+		}
+		if len(commits) < len(dir.commits) {
+			dir.commits = commits
+			dir.recount()
+		}
+		deduped = append(deduped, dir)
+	}
+	return deduped
+}
+
+// recount sets files, changes and authors of dir from its commits.
+func (dir *directory) recount() {
+	changesPerFile := make(map[string]int)
+	authorsPerFile := make(map[string][]string)
+	for _, c := range dir.commits {
+		for _, f := range c.files {
+			changesPerFile[f.path] += f.changes
+			authorsPerFile[f.path] = append(authorsPerFile[f.path], c.author)
+		}
+	}
+	dir.files, dir.changes, dir.authors = nil, 0, nil
+	for path, changes := range changesPerFile {
+		authors := uniq(authorsPerFile[path])
+		dir.files = append(dir.files, file{path: path, changes: changes, authors: authors})
+		dir.changes += changes
+		dir.authors = append(dir.authors, authors...)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import "fmt"
+
+// addPrevious sets previous of directories to the repos at paths analyzed in
+// the window of the same length right before the one selected by opts. The
+// repos are neither fetched nor pulled again.
+func addPrevious(directories []directory, paths []string, opts options) {
+	since, _ := opts.span()
+	allRemotes := opts.allRemotes
+	opts.until, opts.emit, opts.quiet = since, nil, true
+	opts.pull, opts.allRemotes, opts.deepen = false, false, false
+
+	// With -all-remotes, walk the branches the window was taken from.
+	revs := map[string][]string{opts.rev: paths}
+	if allRemotes {
+		revs = make(map[string][]string)
+		for _, dir := range directories {
+			revs[dir.branch] = append(revs[dir.branch], dir.path)
+		}
+	}
+	prev := make(map[string]directory)
+	for rev, paths := range revs {
+		opts.rev = rev
+		for _, dir := range analyze(paths, opts, nil) {
+			prev[dir.path] = dir
+		}
+	}
+	for i := range directories {
+		p := prev[directories[i].path]
+		directories[i].previous = &p
+	}
+}
+
+// delta returns the change of changes in dir, or of the file at path if it's
+// not empty, since the previous window, or "" if it's not known.
+func (dir directory) delta(path string) string {
+	if dir.previous == nil {
+		return ""
+	}
+	if path == "" {
+		return formatDelta(dir.changes, dir.previous.changes)
+	}
+	var n, prev int
+	for _, f := range dir.files {
+		if f.path == path {
+			n = f.changes
+		}
+	}
+	for _, f := range dir.previous.files {
+		if f.path == path {
+			prev = f.changes
+		}
+	}
+	return formatDelta(n, prev)
+}
+
+// formatDelta returns the change from prev to n in percent, or "new" if
+// there was nothing before.
+func formatDelta(n, prev int) string {
+	if prev == 0 {
+		if n == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.0f%%", float64(n-prev)*100/float64(prev))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var htmlReport = template.Must(template.New("report").Parse(`<html>
+<body>
+<p>{{.Summary}}</p>
+<table>
+<tr><th align="left">PATH</th><th align="right">CHANGES</th><th align="left">AUTHORS</th></tr>
+{{range .Repos}}<tr><td>{{.Path}}</td><td align="right">{{.Changes}}</td><td>{{.Authors}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// sendEmail mails the report to recipients using the SMTP settings from c.
+func sendEmail(c smtpConfig, to []string, directories []directory, asHTML bool) error {
+	if c.Host == "" {
+		return errors.New("smtp.host not set in config")
+	}
+	if c.From == "" {
+		return errors.New("smtp.from not set in config")
+	}
+
+	body, contentType, err := emailBody(directories, asHTML)
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", c.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "What you have worked on"))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	fmt.Fprintf(&msg, "Content-Transfer-Encoding: quoted-printable\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+	// Keeps lines short, CRLF-terminated and 7-bit clean, as SMTP wants,
+	// whatever the names of authors and repos.
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+
+	return smtpSend(c, to, msg.Bytes())
+}
+
+func emailBody(directories []directory, asHTML bool) (body, contentType string, err error) {
+	if !asHTML {
+		var buf bytes.Buffer
+		buf.WriteString(summary(directories) + "\n\n")
+		reportResults(&buf, directories)
+		return buf.String(), "text/plain", nil
+	}
+
+	type repo struct {
+		Path    string
+		Changes int
+		Authors string
+	}
+	data := struct {
+		Summary string
+		Repos   []repo
+	}{Summary: summary(directories)}
+	sortDirectories(directories)
+	for _, dir := range directories {
+		data.Repos = append(data.Repos, repo{
+			Path:    displayPath(dir.path),
+			Changes: dir.changes,
+			Authors: formatAuthors(dir.authorChanges(""), *maxAuthors),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := htmlReport.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+	return buf.String(), "text/html", nil
+}
+
+func smtpSend(c smtpConfig, to []string, msg []byte) error {
+	port := c.Port
+	if port == 0 {
+		port = 587
+		if c.TLS == "tls" {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+
+	var client *smtp.Client
+	switch c.TLS {
+	case "tls":
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return err
+		}
+		client, err = smtp.NewClient(conn, c.Host)
+		if err != nil {
+			return err
+		}
+	case "", "starttls", "none":
+		var err error
+		client, err = smtp.Dial(addr)
+		if err != nil {
+			return err
+		}
+		if c.TLS != "none" {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("smtp.tls: want starttls, tls or none, got %q", c.TLS)
+	}
+	defer client.Close()
+
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// generatedShare is the share of changes in generated files that makes a
+// commit generated churn rather than work.
+const generatedShare = 0.8
+
+var (
+	// generatedNames are names of lockfiles and other generated files.
+	generatedNames = map[string]bool{"go.sum": true, "package-lock.json": true, "yarn.lock": true,
+		"pnpm-lock.yaml": true, "npm-shrinkwrap.json": true, "cargo.lock": true, "poetry.lock": true,
+		"pipfile.lock": true, "gemfile.lock": true, "composer.lock": true, "flake.lock": true,
+		"mix.lock": true, "pubspec.lock": true, "podfile.lock": true, "packages.lock.json": true}
+	// generatedSuffixes end names of generated code, like protobufs, and of
+	// minified and snapshot files.
+	generatedSuffixes = []string{".pb.go", ".pb.gw.go", "_pb2.py", "_pb2_grpc.py", ".pb.cc", ".pb.h",
+		"_pb.js", "_pb.d.ts", ".gen.go", "_gen.go", "_generated.go", "_mock.go", "_string.go",
+		".min.js", ".min.css", ".snap", ".lock"}
+	// generatedDirs hold mocks and vendored or generated code.
+	generatedDirs = map[string]bool{"mocks": true, "mock": true, "vendor": true, "node_modules": true,
+		"generated": true, "gen": true, "__snapshots__": true}
+)
+
+// isGenerated tells whether the file at p is likely generated rather than
+// written, by its path.
+func isGenerated(p string) bool {
+	p = strings.ToLower(slashPath(p))
+	base := path.Base(p)
+	if generatedNames[base] || strings.HasPrefix(base, "mock_") || strings.HasPrefix(base, "zz_generated") {
+		return true
+	}
+	for _, s := range generatedSuffixes {
+		if strings.HasSuffix(base, s) {
+			return true
+		}
+	}
+	for _, d := range strings.Split(path.Dir(p), "/") {
+		if generatedDirs[d] {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedChurn tells whether changes of c are mostly in generated files,
+// like a go.sum update.
+func generatedChurn(c commitInfo) bool {
+	var n int
+	for _, f := range c.files {
+		if isGenerated(f.path) {
+			n += f.changes
+		}
+	}
+	return n > 0 && float64(n) >= generatedShare*float64(c.changes)
+}
+
+// generatedChanges returns changes of commits of dir that are generated
+// churn.
+func (dir directory) generatedChanges() int {
+	var n int
+	for _, c := range dir.commits {
+		if c.generated {
+			n += c.changes
+		}
+	}
+	return n
+}
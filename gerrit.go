@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gerritProject returns the Gerrit host in config the repo at remote is
+// cloned from and the name of its project.
+func gerritProject(remote string) (host, project string, ok bool) {
+	host, project, ok = remoteHostPath(remote)
+	if _, configured := conf.Gerrit[host]; !ok || !configured {
+		return "", "", false
+	}
+	// Authenticated HTTP remotes are under /a/.
+	return host, strings.TrimPrefix(project, "a/"), true
+}
+
+// gerritAPI returns the REST API URL of Gerrit at host, authenticated if
+// config has credentials for it.
+func gerritAPI(host string) string {
+	c := conf.Gerrit[host]
+	base := c.URL
+	if base == "" {
+		base = "https://" + host
+	}
+	base = strings.TrimSuffix(base, "/")
+	if c.Username != "" {
+		base += "/a"
+	}
+	return base
+}
+
+// gerritAccount returns the Gerrit account of author from config, or
+// author.
+func gerritAccount(host, author string) string {
+	if account, ok := lookupAuthor(conf.Gerrit[host].Accounts, author); ok {
+		return account
+	}
+	return author
+}
+
+func gerritAuth(host string) func(*http.Request) {
+	return func(r *http.Request) {
+		if c := conf.Gerrit[host]; c.Username != "" {
+			r.SetBasicAuth(c.Username, c.Password)
+		}
+	}
+}
+
+// gerritTime is a timestamp of the Gerrit REST API, in UTC.
+type gerritTime struct {
+	time.Time
+}
+
+func (t *gerritTime) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	t.Time, err = time.Parse("2006-01-02 15:04:05.999999999", s)
+	return err
+}
+
+type gerritUser struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// is tells whether u is account, given as username, email or name, or
+// account is empty.
+func (u gerritUser) is(account string) bool {
+	return account == "" || strings.EqualFold(u.Username, account) ||
+		strings.EqualFold(u.Email, account) || strings.EqualFold(u.Name, account)
+}
+
+type gerritChange struct {
+	Owner     gerritUser  `json:"owner"`
+	Created   gerritTime  `json:"created"`
+	Submitted *gerritTime `json:"submitted"`
+	Revisions map[string]struct {
+		Uploader gerritUser `json:"uploader"`
+		Created  gerritTime `json:"created"`
+	} `json:"revisions"`
+	Messages []struct {
+		Author  gerritUser `json:"author"`
+		Date    gerritTime `json:"date"`
+		Tag     string     `json:"tag"`
+		Message string     `json:"message"`
+	} `json:"messages"`
+	MoreChanges bool `json:"_more_changes"`
+}
+
+// gerritComments matches the count of inline comments Gerrit adds to the
+// message of a review, like "Patch Set 2: Code-Review+1\n\n(3 comments)".
+var gerritComments = regexp.MustCompile(`\((\d+) comments?\)`)
+
+// gerritActivity returns changes of project on Gerrit at host that
+// account, or anyone if it's empty, uploaded, reviewed or got merged, and
+// patch sets uploaded and inline comments written, between since and until.
+// Messages Gerrit generates, like on uploads, and comments on one's own
+// changes aren't reviews.
+func gerritActivity(host, project, account string, since, until time.Time) (prActivity, error) {
+	var a prActivity
+	within := func(t time.Time) bool { return !t.Before(since) && t.Before(until) }
+	query := fmt.Sprintf("project:%q after:%q", project, since.UTC().Format("2006-01-02 15:04:05"))
+	for start := 0; ; {
+		var changes []gerritChange
+		u := fmt.Sprintf("%s/changes/?q=%s&o=ALL_REVISIONS&o=MESSAGES&o=DETAILED_ACCOUNTS&n=100&S=%d",
+			gerritAPI(host), url.QueryEscape(query), start)
+		if _, err := getJSON(u, &changes, gerritAuth(host)); err != nil {
+			return a, err
+		}
+		for _, c := range changes {
+			mine := c.Owner.is(account)
+			if mine && within(c.Created.Time) {
+				a.Opened++
+			}
+			if mine && c.Submitted != nil && within(c.Submitted.Time) {
+				a.Merged++
+			}
+			for _, r := range c.Revisions {
+				if r.Uploader.is(account) && within(r.Created.Time) {
+					a.Patchsets++
+				}
+			}
+			var reviewed bool
+			for _, m := range c.Messages {
+				if m.Author.is(account) && m.Author != c.Owner && within(m.Date.Time) && !strings.HasPrefix(m.Tag, "autogenerated:") {
+					reviewed = true
+					if sm := gerritComments.FindStringSubmatch(m.Message); sm != nil {
+						n, _ := strconv.Atoi(sm[1])
+						a.Comments += n
+					}
+				}
+			}
+			if reviewed {
+				a.Reviewed++
+			}
+		}
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			return a, nil
+		}
+		start += len(changes)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitCLIRepo is a git repo read by the git command. It computes changes
+// much faster than go-git in large repos.
+type gitCLIRepo struct {
+	path string
+}
+
+func openGitCLIRepo(path string) (gitCLIRepo, error) {
+	r := gitCLIRepo{path: path}
+	_, err := r.git("rev-parse", "--git-dir")
+	return r, err
+}
+
+func (r gitCLIRepo) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.path}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (r gitCLIRepo) head() (string, error) {
+	out, err := r.git("rev-parse", "HEAD")
+	return strings.TrimSpace(string(out)), err
+}
+
+func (r gitCLIRepo) pull() error {
+	if _, err := r.git("symbolic-ref", "--quiet", "HEAD"); err != nil {
+		// There's no branch to merge into.
+		if _, err := r.git("fetch", "--quiet"); err != nil {
+			return err
+		}
+		branch, err := r.defaultBranch("origin")
+		if err != nil {
+			return err
+		}
+		return &detachedError{branch: branch}
+	}
+	_, err := r.git("pull", "--ff-only", "--quiet")
+	return err
+}
+
+// defaultBranch returns the default branch of remote, like origin/main.
+func (r gitCLIRepo) defaultBranch(remote string) (string, error) {
+	if out, err := r.git("symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD"); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+	for _, b := range []string{"main", "master"} {
+		if _, err := r.git("rev-parse", "--verify", "--quiet", "refs/remotes/"+remote+"/"+b); err == nil {
+			return remote + "/" + b, nil
+		}
+	}
+	return "", fmt.Errorf("remote %s has no default branch", remote)
+}
+
+func (r gitCLIRepo) fetchRemotes() (string, error) {
+	if _, err := r.git("fetch", "--all", "--quiet"); err != nil {
+		return "", err
+	}
+	out, err := r.git("remote")
+	if err != nil {
+		return "", err
+	}
+	var freshest string
+	var newest int64
+	for _, remote := range strings.Fields(string(out)) {
+		branch, err := r.defaultBranch(remote)
+		if err != nil {
+			continue
+		}
+		out, err := r.git("log", "-1", "--format=%ct", branch)
+		if err != nil {
+			return "", err
+		}
+		t, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if freshest == "" || t > newest {
+			freshest, newest = branch, t
+		}
+	}
+	if freshest == "" {
+		return "", errors.New("no remote has a default branch")
+	}
+	return freshest, nil
+}
+
+func (r gitCLIRepo) shallowStart() (time.Time, error) {
+	out, err := r.git("rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return time.Time{}, err
+	}
+	shallow := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(shallow) {
+		shallow = filepath.Join(r.path, shallow)
+	}
+	b, err := os.ReadFile(shallow)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	hashes := strings.Fields(string(b))
+	if len(hashes) == 0 {
+		return time.Time{}, nil
+	}
+	out, err = r.git(append([]string{"show", "--no-patch", "--format=%ct"}, hashes...)...)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var start time.Time
+	for _, f := range strings.Fields(string(out)) {
+		sec, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t := time.Unix(sec, 0); t.After(start) {
+			start = t
+		}
+	}
+	return start, nil
+}
+
+// deepen fetches twice as many older commits each time until the history
+// reaches back to since.
+func (r gitCLIRepo) deepen(since time.Time) error {
+	start, err := r.shallowStart()
+	for n := 64; err == nil && start.After(since); n *= 2 {
+		if _, err := r.git("fetch", "--quiet", "--deepen="+strconv.Itoa(n)); err != nil {
+			return err
+		}
+		prev := start
+		if start, err = r.shallowStart(); err == nil && start.Equal(prev) {
+			return fmt.Errorf("history still starts at %s", start.Format("2006-01-02"))
+		}
+	}
+	return err
+}
+
+func (r gitCLIRepo) log(opts options, lastTip string) ([]commitInfo, error) {
+	// Header of each commit, followed by its numstat lines.
+	format := "%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%cI%x1f%x1f%B%x1f"
+	if opts.verifySignatures {
+		format = "%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%cI%x1f%G?%x1f%B%x1f"
+	}
+	// Merges bring changes of other commits so git shows no stats for them.
+	// Renames count as deleting the old file and adding the new one, as the
+	// other backends count them.
+	args := []string{"log", "--numstat", "--no-renames", "--format=" + format}
+	if opts.discountMoved || opts.allBranches {
+		// Moved lines and patch IDs are found in the lines of the patch.
+		args = []string{"log", "--patch", "--no-renames", "--no-color", "--no-ext-diff", "--format=" + format}
+	}
+	if opts.allBranches {
+		args = append(args, "--branches")
+	} else if opts.rev != "" {
+		args = append(args, opts.rev)
+	}
+	var pathspecs []string
+	if globs := opts.pathGlobs(); globs != nil {
+		pathspecs = append(pathspecs, "--")
+		magic := ":(glob)"
+		if foldCase {
+			magic = ":(glob,icase)"
+		}
+		for _, g := range globs {
+			pathspecs = append(pathspecs, magic+g)
+		}
+	}
+
+	var out []byte
+	var err error
+	if lastTip != "" {
+		out, err = r.git(append(append(args, "HEAD", "^"+lastTip), pathspecs...)...)
+		// If the tip is gone the history was rewritten, fall back to the
+		// time window.
+	}
+	var since, until time.Time
+	if lastTip == "" || err != nil {
+		since, until = opts.span()
+		// Committer date is never before author date, so limiting by
+		// committer date selects all candidates for either date.
+		out, err = r.git(append(append(args, fmt.Sprintf("--since=@%d", since.Unix())), pathspecs...)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []commitInfo
+	for _, rec := range strings.Split(string(out), "\x1e")[1:] {
+		fields := strings.SplitN(rec, "\x1f", 8)
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("git log: unexpected output %q", rec)
+		}
+		date := fields[4]
+		if opts.authorDate {
+			date = fields[3]
+		}
+		when, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return nil, err
+		}
+		if !since.IsZero() && (when.Before(since) || when.After(until)) {
+			continue
+		}
+		msg := fields[6]
+		c := commitInfo{
+			hash:    fields[0],
+			author:  fields[1],
+			email:   fields[2],
+			when:    when,
+			subject: strings.SplitN(msg, "\n", 2)[0],
+			message: msg,
+			signed:  fields[5] == "G" || fields[5] == "U",
+			signoff: signedOffByAuthor(msg, fields[1], fields[2]),
+		}
+		if !opts.selects(c) {
+			continue
+		}
+		if opts.discountMoved || opts.allBranches {
+			patch, err := parsePatch(fields[7])
+			if err != nil {
+				return nil, fmt.Errorf("git log: patch of %s: %v", c.hash, err)
+			}
+			c.files = fileChanges(patch, opts.discountMoved)
+			if opts.allBranches {
+				c.patchID = patchID(patch)
+			}
+		} else {
+			c.files = parseNumstat(fields[7])
+		}
+		for _, f := range c.files {
+			c.changes += f.changes
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// parseNumstat returns changes per file from git log --numstat output.
+// Binary files, shown as "-", have no changes.
+func parseNumstat(s string) []fileChange {
+	var files []fileChange
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		files = append(files, fileChange{path: fields[2], changes: added + deleted, net: added - deleted})
+	}
+	return files
+}
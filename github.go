@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubRepo returns the API URL of GitHub, or of the GitHub Enterprise
+// server in config, hosting the repo at remote, and its owner/name.
+func githubRepo(remote string) (api, repo string, ok bool) {
+	host, repo, ok := remoteHostPath(remote)
+	if !ok || strings.Count(repo, "/") != 1 {
+		return "", "", false
+	}
+	if host == "github.com" {
+		return "https://api.github.com", repo, true
+	}
+	if u, err := url.Parse(conf.GitHub.URL); err == nil && u.Host != "" && u.Hostname() == host {
+		return strings.TrimSuffix(conf.GitHub.URL, "/") + "/api/v3", repo, true
+	}
+	return "", "", false
+}
+
+// githubLogin returns the GitHub login of author from config, or author.
+func githubLogin(author string) string {
+	if login, ok := lookupAuthor(conf.GitHub.Logins, author); ok {
+		return login
+	}
+	return author
+}
+
+func githubAuth(r *http.Request) {
+	token := conf.GitHub.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.Header.Set("Accept", "application/vnd.github+json")
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubPull struct {
+	Number    int        `json:"number"`
+	User      githubUser `json:"user"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	MergedAt  *time.Time `json:"merged_at"`
+}
+
+type githubComment struct {
+	User           githubUser `json:"user"`
+	CreatedAt      time.Time  `json:"created_at"`
+	PullRequestURL string     `json:"pull_request_url"`
+}
+
+type githubReview struct {
+	User        githubUser `json:"user"`
+	SubmittedAt *time.Time `json:"submitted_at"` // nil while pending
+}
+
+// githubActivity returns pull requests of repo that login, or anyone if
+// it's empty, opened, reviewed or got merged between since and until, and
+// review comments written meanwhile. Reviewing one's own pull request
+// doesn't count.
+func githubActivity(api, repo, login string, since, until time.Time) (prActivity, error) {
+	var a prActivity
+	within := func(t time.Time) bool { return !t.Before(since) && t.Before(until) }
+	authors := make(map[int]string)
+	next := fmt.Sprintf("%s/repos/%s/pulls?state=all&sort=updated&direction=desc&per_page=100", api, repo)
+pages:
+	for next != "" {
+		var pulls []githubPull
+		var err error
+		if next, err = getJSON(next, &pulls, githubAuth); err != nil {
+			return a, err
+		}
+		for _, p := range pulls {
+			if p.UpdatedAt.Before(since) {
+				// Pull requests updated earlier saw no activity since.
+				break pages
+			}
+			authors[p.Number] = p.User.Login
+			mine := login == "" || strings.EqualFold(p.User.Login, login)
+			if mine && within(p.CreatedAt) {
+				a.Opened++
+			}
+			if mine && p.MergedAt != nil && within(*p.MergedAt) {
+				a.Merged++
+			}
+			reviewed, err := githubReviewed(api, repo, p, login, within)
+			if err != nil {
+				return a, err
+			}
+			if reviewed {
+				a.Reviewed++
+			}
+		}
+	}
+	var err error
+	a.Comments, err = githubComments(api, repo, login, authors, since, within)
+	return a, err
+}
+
+// githubReviewed tells whether login, or anyone if it's empty, reviewed
+// pull request p of someone else at a time within the window.
+func githubReviewed(api, repo string, p githubPull, login string, within func(time.Time) bool) (bool, error) {
+	if login != "" && strings.EqualFold(p.User.Login, login) {
+		return false, nil
+	}
+	next := fmt.Sprintf("%s/repos/%s/pulls/%d/reviews?per_page=100", api, repo, p.Number)
+	for next != "" {
+		var reviews []githubReview
+		var err error
+		if next, err = getJSON(next, &reviews, githubAuth); err != nil {
+			return false, err
+		}
+		for _, r := range reviews {
+			by := r.User.Login
+			if r.SubmittedAt == nil || !within(*r.SubmittedAt) || strings.EqualFold(by, p.User.Login) {
+				continue
+			}
+			if login == "" || strings.EqualFold(by, login) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// githubComments returns how many review comments on the diffs of pull
+// requests of someone else login, or anyone if it's empty, wrote within the
+// window. authors are logins of authors of pull requests updated since, by
+// number.
+func githubComments(api, repo, login string, authors map[int]string, since time.Time, within func(time.Time) bool) (int, error) {
+	var n int
+	next := fmt.Sprintf("%s/repos/%s/pulls/comments?sort=updated&direction=desc&since=%s&per_page=100",
+		api, repo, url.QueryEscape(since.UTC().Format(time.RFC3339)))
+	for next != "" {
+		var comments []githubComment
+		var err error
+		if next, err = getJSON(next, &comments, githubAuth); err != nil {
+			return n, err
+		}
+		for _, c := range comments {
+			number, _ := strconv.Atoi(path.Base(c.PullRequestURL))
+			by := c.User.Login
+			if !within(c.CreatedAt) || strings.EqualFold(by, authors[number]) {
+				continue
+			}
+			if login == "" || strings.EqualFold(by, login) {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// gitlabProject returns the host of GitLab, gitlab.com or a host in
+// config, hosting the repo at remote, and its path like group/project.
+func gitlabProject(remote string) (host, project string, ok bool) {
+	host, project, ok = remoteHostPath(remote)
+	if !ok || !strings.Contains(project, "/") {
+		return "", "", false
+	}
+	if _, configured := conf.GitLab[host]; !configured && host != "gitlab.com" {
+		return "", "", false
+	}
+	return host, project, true
+}
+
+// gitlabAPI returns the API URL of GitLab at host.
+func gitlabAPI(host string) string {
+	base := conf.GitLab[host].URL
+	if base == "" {
+		base = "https://" + host
+	}
+	return strings.TrimSuffix(base, "/") + "/api/v4"
+}
+
+// gitlabUsername returns the username of author on GitLab at host from
+// config, or author.
+func gitlabUsername(host, author string) string {
+	if username, ok := lookupAuthor(conf.GitLab[host].Usernames, author); ok {
+		return username
+	}
+	return author
+}
+
+func gitlabAuth(host string) func(*http.Request) {
+	return func(r *http.Request) {
+		if token := conf.GitLab[host].Token; token != "" {
+			r.Header.Set("PRIVATE-TOKEN", token)
+		}
+	}
+}
+
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+type gitlabMergeRequest struct {
+	IID       int        `json:"iid"`
+	Author    gitlabUser `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	MergedAt  *time.Time `json:"merged_at"`
+}
+
+type gitlabNote struct {
+	Author    gitlabUser `json:"author"`
+	Body      string     `json:"body"`
+	System    bool       `json:"system"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// gitlabActivity returns merge requests of project on GitLab at host that
+// username, or anyone if it's empty, opened, approved or got merged between
+// since and until, and comments written on them meanwhile. Approving or
+// commenting one's own merge request doesn't count.
+func gitlabActivity(host, project, username string, since, until time.Time) (prActivity, error) {
+	var a prActivity
+	within := func(t time.Time) bool { return !t.Before(since) && t.Before(until) }
+	api := fmt.Sprintf("%s/projects/%s", gitlabAPI(host), url.PathEscape(project))
+	next := fmt.Sprintf("%s/merge_requests?scope=all&state=all&order_by=updated_at&sort=desc&updated_after=%s&per_page=100",
+		api, url.QueryEscape(since.UTC().Format(time.RFC3339)))
+	for next != "" {
+		var mrs []gitlabMergeRequest
+		var err error
+		if next, err = getJSON(next, &mrs, gitlabAuth(host)); err != nil {
+			return a, err
+		}
+		for _, mr := range mrs {
+			mine := username == "" || strings.EqualFold(mr.Author.Username, username)
+			if mine && within(mr.CreatedAt) {
+				a.Opened++
+			}
+			if mine && mr.MergedAt != nil && within(*mr.MergedAt) {
+				a.Merged++
+			}
+			approved, comments, err := gitlabReview(host, api, mr, username, within)
+			if err != nil {
+				return a, err
+			}
+			if approved {
+				a.Reviewed++
+			}
+			a.Comments += comments
+		}
+	}
+	return a, nil
+}
+
+// gitlabReview tells whether username, or anyone if it's empty, approved
+// merge request mr of someone else at a time within the window, and how
+// many comments they wrote on it meanwhile. Approvals are found in the
+// system notes of mr, which unlike the approvals API say when they were
+// given.
+func gitlabReview(host, api string, mr gitlabMergeRequest, username string, within func(time.Time) bool) (approved bool, comments int, err error) {
+	if username != "" && strings.EqualFold(mr.Author.Username, username) {
+		return false, 0, nil
+	}
+	next := fmt.Sprintf("%s/merge_requests/%d/notes?per_page=100", api, mr.IID)
+	for next != "" {
+		var notes []gitlabNote
+		if next, err = getJSON(next, &notes, gitlabAuth(host)); err != nil {
+			return false, 0, err
+		}
+		for _, n := range notes {
+			by := n.Author.Username
+			if !within(n.CreatedAt) || strings.EqualFold(by, mr.Author.Username) || username != "" && !strings.EqualFold(by, username) {
+				continue
+			}
+			switch {
+			case !n.System:
+				comments++
+			case n.Body == "approved this merge request":
+				approved = true
+			}
+		}
+	}
+	return approved, comments, nil
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	commitgraphfmt "github.com/go-git/go-git/v5/plumbing/format/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// stopSlack is how much older than the window commits have to be to stop
+// walking the history.
+const stopSlack = 24 * time.Hour
+
+// gitRepo is a git repo read by go-git.
+type gitRepo struct {
+	repo *git.Repository
+}
+
+// openGitRepo opens repo at path, with object cache of -object-cache size if
+// it's set.
+func openGitRepo(path string) (gitRepo, error) {
+	dotGit := filepath.Join(path, ".git")
+	if fi, err := os.Stat(dotGit); *objectCache <= 0 || err != nil || !fi.IsDir() {
+		// Default cache, bare repo or worktree with .git file.
+		repo, err := git.PlainOpen(path)
+		return gitRepo{repo}, err
+	}
+	objects := cache.NewObjectLRU(cache.FileSize(*objectCache) * cache.MiByte)
+	repo, err := git.Open(filesystem.NewStorage(osfs.New(dotGit), objects), osfs.New(path))
+	return gitRepo{repo}, err
+}
+
+func (r gitRepo) head() (string, error) {
+	ref, err := r.repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return ref.Hash().String(), nil
+}
+
+func (r gitRepo) log(opts options, lastTip string) ([]commitInfo, error) {
+	var forEach func(func(*object.Commit) error) error
+	if lastTip != "" {
+		cIter, err := commitsSince(r.repo, plumbing.NewHash(lastTip))
+		switch {
+		case errors.Is(err, plumbing.ErrObjectNotFound):
+			// History was rewritten, fall back to the time window.
+		case err != nil:
+			return nil, err
+		default:
+			forEach = cIter.ForEach
+		}
+	}
+	var since, until, stop time.Time
+	if forEach == nil {
+		since, until = opts.span()
+		// Committer date is never before author date, so limiting by
+		// committer date selects all candidates for either date. Commits
+		// are visited newest first, so the walk can stop once they get
+		// older than the window. Some slack lets commits made on machines
+		// with a skewed clock in.
+		stop = since.Add(-stopSlack)
+		var err error
+		switch {
+		case opts.allBranches:
+			forEach, err = r.branchesLog()
+		case opts.rev != "":
+			forEach, err = r.revLog(opts.rev)
+		default:
+			forEach, err = r.graphLog(stop)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if forEach == nil {
+			cIter, err := r.repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+			if err != nil {
+				return nil, err
+			}
+			forEach = cIter.ForEach
+		}
+	}
+
+	var commits []commitInfo
+	err := forEach(func(commit *object.Commit) error {
+		if !stop.IsZero() && commit.Committer.When.Before(stop) {
+			return storer.ErrStop
+		}
+		when := commit.Committer.When
+		if opts.authorDate {
+			when = commit.Author.When
+		}
+		if !since.IsZero() && (when.Before(since) || when.After(until)) {
+			return nil
+		}
+
+		c := commitInfo{
+			hash:    commit.Hash.String(),
+			author:  commit.Author.Name,
+			email:   commit.Author.Email,
+			when:    when,
+			subject: strings.SplitN(commit.Message, "\n", 2)[0],
+			message: commit.Message,
+			signoff: signedOffByAuthor(commit.Message, commit.Author.Name, commit.Author.Email),
+		}
+		if opts.verifySignatures && commit.PGPSignature != "" {
+			c.signed = verifySignature(r.repo, commit.Hash)
+		}
+		if !opts.selects(c) {
+			return nil
+		}
+
+		if commit.NumParents() <= 1 { // merges bring changes of other commits
+			files, id, err := commitChanges(commit, opts)
+			if err != nil {
+				return err
+			}
+			c.patchID = id
+			for _, f := range files {
+				c.changes += f.changes
+			}
+			c.files = files
+		}
+
+		commits = append(commits, c)
+		return nil
+	})
+	return commits, err
+}
+
+// commitChanges returns changes per file of commit compared to its first
+// parent and, with -all-branches, its patch ID. Only files kept by opts are
+// diffed. Renames count as deleting the old file and adding the new one, like
+// git log --numstat --no-renames does, rather than as no change at all.
+func commitChanges(commit *object.Commit, opts options) ([]fileChange, string, error) {
+	patch, err := commitPatch(commit, opts)
+	if err != nil || patch == nil {
+		return nil, "", err
+	}
+	if opts.discountMoved || opts.allBranches {
+		files := patchFiles(patch)
+		var id string
+		if opts.allBranches {
+			id = patchID(files)
+		}
+		return fileChanges(files, opts.discountMoved), id, nil
+	}
+
+	var files []fileChange
+	for _, stat := range patch.Stats() {
+		file, nChanges := parseStat(stat)
+		if file != "" { // only content changes
+			files = append(files, fileChange{path: file, changes: nChanges, net: stat.Addition - stat.Deletion})
+		}
+	}
+	return files, "", nil
+}
+
+// commitPatch returns the patch of commit against its first parent with
+// files kept by opts, or nil if no such file changed.
+func commitPatch(commit *object.Commit, opts options) (*object.Patch, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	parentTree := &object.Tree{}
+	if commit.NumParents() != 0 {
+		parent, err := commit.Parents().Next()
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+	var kept object.Changes
+	for _, ch := range changes {
+		if opts.keepsPath(ch.From.Name) || opts.keepsPath(ch.To.Name) {
+			kept = append(kept, ch)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil
+	}
+	return kept.Patch()
+}
+
+// patchFiles returns lines added and deleted per file in patch. Binary files
+// have no lines.
+func patchFiles(patch *object.Patch) []patchFile {
+	var files []patchFile
+	for _, fp := range patch.FilePatches() {
+		from, to := fp.Files()
+		f := patchFile{}
+		if to != nil {
+			f.path = to.Path()
+		} else if from != nil {
+			f.path = from.Path()
+		}
+		for _, chunk := range fp.Chunks() {
+			lines := strings.SplitAfter(chunk.Content(), "\n")
+			if lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			switch chunk.Type() {
+			case diff.Add:
+				f.added = append(f.added, lines...)
+			case diff.Delete:
+				f.deleted = append(f.deleted, lines...)
+			}
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// graphLog returns a function iterating over commits reachable from HEAD
+// committed since, newest first. Commit times and parents are read from the
+// commit-graph file, so only commits within the window are loaded and the
+// walk stops at the first commit older than since. If the repo has no
+// usable commit-graph graphLog returns nil.
+func (r gitRepo) graphLog(since time.Time) (func(func(*object.Commit) error) error, error) {
+	s, ok := r.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, nil
+	}
+	f, err := s.Filesystem().Open(filepath.Join("objects", "info", "commit-graph"))
+	if err != nil {
+		return nil, nil
+	}
+	index, err := commitgraphfmt.OpenFileIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, nil
+	}
+	ref, err := r.repo.Head()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	head, err := commitgraph.NewGraphCommitNodeIndex(index, s).Get(ref.Hash())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func(fn func(*object.Commit) error) error {
+		defer f.Close()
+		return commitgraph.NewCommitNodeIterCTime(head, nil, nil).ForEach(func(n commitgraph.CommitNode) error {
+			if n.CommitTime().Before(since) {
+				return storer.ErrStop
+			}
+			c, err := n.Commit()
+			if err != nil {
+				return err
+			}
+			return fn(c)
+		})
+	}, nil
+}
+
+// revLog returns a function iterating over commits reachable from rev, newest
+// first.
+func (r gitRepo) revLog(rev string) (func(func(*object.Commit) error) error, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, err
+	}
+	cIter, err := r.repo.Log(&git.LogOptions{From: *hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	return cIter.ForEach, nil
+}
+
+// branchesLog returns a function iterating over commits reachable from local
+// branches. Each branch is walked newest first, commits reachable from more
+// branches are visited once.
+func (r gitRepo) branchesLog() (func(func(*object.Commit) error) error, error) {
+	refs, err := r.repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	var heads []plumbing.Hash
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		heads = append(heads, ref.Hash())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(fn func(*object.Commit) error) error {
+		seen := make(map[plumbing.Hash]bool)
+		for _, h := range heads {
+			cIter, err := r.repo.Log(&git.LogOptions{From: h, Order: git.LogOrderCommitterTime})
+			if err != nil {
+				return err
+			}
+			err = cIter.ForEach(func(c *object.Commit) error {
+				if seen[c.Hash] {
+					return nil
+				}
+				seen[c.Hash] = true
+				return fn(c)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func (r gitRepo) pull() error {
+	repo, err := r.remoteRepo()
+	if err != nil {
+		return err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	origin, err := repo.Remote("origin")
+	if err != nil {
+		return err
+	}
+	auth, err := remoteAuth(origin)
+	if err != nil {
+		return err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	if !head.Name().IsBranch() {
+		// There's no branch to merge into.
+		err := repo.Fetch(&git.FetchOptions{Auth: auth})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return err
+		}
+		branch, err := r.defaultBranch("origin")
+		if err != nil {
+			return err
+		}
+		return &detachedError{branch: branch}
+	}
+
+	err = w.Pull(&git.PullOptions{
+		Auth: auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+func (r gitRepo) shallowStart() (time.Time, error) {
+	hashes, err := r.repo.Storer.Shallow()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var start time.Time
+	for _, h := range hashes {
+		c, err := r.repo.CommitObject(h)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if c.Committer.When.After(start) {
+			start = c.Committer.When
+		}
+	}
+	return start, nil
+}
+
+// deepen uses the git command as go-git can't deepen shallow clones.
+func (r gitRepo) deepen(since time.Time) error {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return gitCLIRepo{path: w.Filesystem.Root()}.deepen(since)
+}
+
+// defaultBranch returns the default branch of remote, like origin/main.
+func (r gitRepo) defaultBranch(remote string) (string, error) {
+	ref, err := r.repo.Reference(plumbing.NewRemoteHEADReferenceName(remote), false)
+	if err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().String(), "refs/remotes/"), nil
+	}
+	for _, b := range []string{"main", "master"} {
+		if _, err := r.repo.Reference(plumbing.NewRemoteReferenceName(remote, b), false); err == nil {
+			return remote + "/" + b, nil
+		}
+	}
+	return "", fmt.Errorf("remote %s has no default branch", remote)
+}
+
+func (r gitRepo) fetchRemotes() (string, error) {
+	repo, err := r.remoteRepo()
+	if err != nil {
+		return "", err
+	}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return "", err
+	}
+	var freshest string
+	var newest time.Time
+	for _, remote := range remotes {
+		auth, err := remoteAuth(remote)
+		if err != nil {
+			return "", err
+		}
+		err = remote.Fetch(&git.FetchOptions{Auth: auth})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return "", err
+		}
+		branch, err := r.defaultBranch(remote.Config().Name)
+		if err != nil {
+			continue
+		}
+		hash, err := r.repo.ResolveRevision(plumbing.Revision(branch))
+		if err != nil {
+			return "", err
+		}
+		c, err := r.repo.CommitObject(*hash)
+		if err != nil {
+			return "", err
+		}
+		if freshest == "" || c.Committer.When.After(newest) {
+			freshest, newest = branch, c.Committer.When
+		}
+	}
+	if freshest == "" {
+		return "", errors.New("no remote has a default branch")
+	}
+	return freshest, nil
+}
+
+func parseStat(stat object.FileStat) (file string, nChanges int) {
+	count := make(map[string]int)
+	if _, ok := count[stat.Name]; !ok {
+		count[stat.Name]++
+	}
+	file = stat.Name
+	nChanges += stat.Addition
+	nChanges += stat.Deletion
+	for _, v := range count {
+		if v > 1 {
+			log.Fatalf("didn't expect this: %v", count)
+		}
+	}
+	return
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCommitChangesRename(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(msg string) *object.Commit {
+		hash, err := wt.Commit(msg, &git.CommitOptions{
+			All:    true,
+			Author: &object.Signature{Name: "Al", Email: "al@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := repo.CommitObject(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	content := strings.Repeat("line\n", 10)
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("old.txt"); err != nil {
+		t.Fatal(err)
+	}
+	commit("Add old.txt")
+	if _, err := wt.Move("old.txt", "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+	renamed := commit("Rename old.txt to new.txt")
+
+	// As git log --numstat --no-renames counts it.
+	want := []fileChange{
+		{path: "new.txt", changes: 10, net: 10},
+		{path: "old.txt", changes: 10, net: -10},
+	}
+	for _, opts := range []options{{}, {exts: []string{"txt"}}} {
+		files, _, err := commitChanges(renamed, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("commitChanges with %+v = %+v, want %+v", opts, files, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// harvestEntry is time spent on a Harvest project task during one day.
+type harvestEntry struct {
+	project harvestProject
+	repoDay
+}
+
+// harvestEntries maps daily time spent per repo to the Harvest projects that
+// repos are mapped to in config. Names of repos that aren't mapped are
+// returned as unmapped.
+func harvestEntries(sessions []session, projects map[string]harvestProject) (entries []harvestEntry, unmapped []string) {
+	for _, rd := range repoDays(sessions) {
+		project, ok := projects[rd.repo]
+		if !ok {
+			project, ok = projects[repoName(rd.repo)]
+		}
+		if !ok {
+			unmapped = append(unmapped, repoName(rd.repo))
+			continue
+		}
+		entries = append(entries, harvestEntry{project: project, repoDay: rd})
+	}
+	return entries, uniq(unmapped)
+}
+
+// createHarvestEntries creates daily time entries in Harvest and marks their
+// commits as posted. If dryRun is true it only prints what would be created.
+func createHarvestEntries(entries []harvestEntry, posted postedCommits, dryRun bool) error {
+	if dryRun {
+		printHarvestEntries(os.Stdout, entries)
+		return nil
+	}
+
+	c := conf.Harvest
+	if c.Token == "" || c.AccountID == 0 {
+		return fmt.Errorf("harvest.token and harvest.account_id must be set in config")
+	}
+	auth := func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+c.Token)
+		r.Header.Set("Harvest-Account-Id", strconv.Itoa(c.AccountID))
+		r.Header.Set("User-Agent", "workedon (https://github.com/jreisinger/workedon)")
+	}
+
+	for _, e := range entries {
+		body := map[string]interface{}{
+			"project_id": e.project.ProjectID,
+			"task_id":    e.project.TaskID,
+			"spent_date": e.date,
+			"hours":      roundHours(e.spent),
+			"notes":      strings.Join(e.subjects, "\n"),
+		}
+		if err := postJSON("https://api.harvestapp.com/v2/time_entries", body, auth); err != nil {
+			return err
+		}
+		posted.mark("harvest", e.commits)
+	}
+	return nil
+}
+
+// roundHours returns d in hours rounded to two decimal places.
+func roundHours(d time.Duration) float64 {
+	return float64(d.Round(36*time.Second)) / float64(time.Hour)
+}
+
+func printHarvestEntries(w io.Writer, entries []harvestEntry) {
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "DATE", "REPO", "PROJECT/TASK", "HOURS", "NOTES")
+	for _, e := range entries {
+		fmt.Fprintf(tw, format, e.date, exportName(e.repo),
+			fmt.Sprintf("%d/%d", e.project.ProjectID, e.project.TaskID),
+			roundHours(e.spent), strings.Join(e.subjects, "; "))
+	}
+	tw.Flush()
+}
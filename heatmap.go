@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Heatmap layout in pixels.
+const (
+	heatCell   = 10
+	heatGap    = 3
+	heatLeft   = 30 // weekday labels
+	heatTop    = 35 // title and month labels
+	heatWeeks  = 53
+	heatMargin = 10
+)
+
+// heatColors are the colors of days without commits and of the four
+// quartiles of days with commits, like on GitHub.
+var heatColors = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+// heatmapStart returns the first day of the heatmap ending at now: the
+// Sunday of the week a year ago.
+func heatmapStart(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return today.AddDate(0, 0, -(heatWeeks-1)*7-int(today.Weekday()))
+}
+
+// writeHeatmap writes an SVG heatmap of commits per day in directories, a
+// column per week of the year until now.
+func writeHeatmap(w io.Writer, directories []directory, now time.Time) {
+	perDay := make(map[string]int)
+	var total int
+	for _, dir := range directories {
+		for _, c := range dir.commits {
+			perDay[c.when.In(loc).Format("2006-01-02")]++
+			total++
+		}
+	}
+
+	// Days with commits are colored by the quartile of their count.
+	var counts []int
+	for _, n := range perDay {
+		counts = append(counts, n)
+	}
+	sort.Ints(counts)
+	level := func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		l := 1
+		for q := 1; q < 4; q++ {
+			if n > counts[len(counts)*q/4] {
+				l = q + 1
+			}
+		}
+		return l
+	}
+
+	width := heatLeft + heatWeeks*(heatCell+heatGap) + heatMargin
+	height := heatTop + 7*(heatCell+heatGap) + heatMargin
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="10" fill="#57606a">`+"\n", width, height)
+	fmt.Fprintf(w, `<text x="%d" y="12" font-size="12">%s in the last year</text>`+"\n", heatMargin, plural(total, "commit"))
+	for i, name := range []string{"Mon", "Wed", "Fri"} {
+		fmt.Fprintf(w, `<text x="0" y="%d">%s</text>`+"\n", heatTop+(2*i+1)*(heatCell+heatGap)+heatCell-1, name)
+	}
+
+	start := heatmapStart(now)
+	month := time.Month(0)
+	for day := start; !day.After(now); day = day.AddDate(0, 0, 1) {
+		week := int(day.Sub(start).Hours()/24+0.5) / 7
+		x := heatLeft + week*(heatCell+heatGap)
+		if day.Weekday() == time.Sunday && day.Month() != month {
+			month = day.Month()
+			if week < heatWeeks-2 { // leave room for the label
+				fmt.Fprintf(w, `<text x="%d" y="%d">%s</text>`+"\n", x, heatTop-6, day.Format("Jan"))
+			}
+		}
+		key := day.Format("2006-01-02")
+		n := perDay[key]
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s on %s</title></rect>`+"\n",
+			x, heatTop+int(day.Weekday())*(heatCell+heatGap), heatCell, heatCell, heatColors[level(n)], plural(n, "commit"), key)
+	}
+	fmt.Fprintln(w, "</svg>")
+}
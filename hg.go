@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hgRepo is a Mercurial repo read by the hg command.
+type hgRepo struct {
+	path string
+}
+
+// hgTemplate prints a header of each commit, which is followed by its patch.
+// Fields are separated by the unit separator.
+const hgTemplate = "\x1e{node}\x1f{author|person}\x1f{author|email}\x1f{date|hgdate}\x1f{p2rev}\x1f{desc}\x1f\n"
+
+func (r hgRepo) hg(args ...string) ([]byte, error) {
+	cmd := exec.Command("hg", append([]string{"--cwd", r.path, "--noninteractive"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("hg %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (r hgRepo) head() (string, error) {
+	out, err := r.hg("log", "-r", ".", "-T", "{node}")
+	return string(out), err
+}
+
+func (r hgRepo) pull() error {
+	_, err := r.hg("pull", "--update")
+	return err
+}
+
+func (r hgRepo) log(opts options, lastTip string) ([]commitInfo, error) {
+	args := []string{"log", "--git", "--patch", "-T", hgTemplate}
+	for _, g := range opts.pathGlobs() {
+		args = append(args, "-I", "glob:"+g)
+	}
+
+	var out []byte
+	var err error
+	if lastTip != "" {
+		out, err = r.hg(append(args, "-r", fmt.Sprintf("only(., %s)", lastTip))...)
+		// If the tip is gone the history was rewritten, fall back to the
+		// time window.
+	}
+	var since, until time.Time
+	if lastTip == "" || err != nil {
+		since, until = opts.span()
+		// Mercurial has only one date per commit.
+		revs := fmt.Sprintf("date('>%d 0')", since.Unix())
+		if !opts.allBranches {
+			// Like git, only what leads to the working directory.
+			revs = "ancestors(.) and " + revs
+		}
+		out, err = r.hg(append(args, "-r", revs)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []commitInfo
+	for _, rec := range strings.Split(string(out), "\x1e")[1:] {
+		fields := strings.SplitN(rec, "\x1f", 7)
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("hg log: unexpected output %q", rec)
+		}
+		when, err := parseHgDate(fields[3])
+		if err != nil {
+			return nil, err
+		}
+		if !since.IsZero() && (when.Before(since) || when.After(until)) {
+			continue
+		}
+		msg := fields[5]
+		c := commitInfo{
+			hash:    fields[0],
+			author:  fields[1],
+			email:   fields[2],
+			when:    when,
+			subject: strings.SplitN(msg, "\n", 2)[0],
+			message: msg,
+			signoff: signedOffByAuthor(msg, fields[1], fields[2]),
+		}
+		if !opts.selects(c) {
+			continue
+		}
+		if fields[4] == "-1" { // merges bring changes of other commits
+			patch, err := parsePatch(fields[6])
+			if err != nil {
+				return nil, fmt.Errorf("hg log: patch of %s: %v", c.hash, err)
+			}
+			c.files = fileChanges(patch, opts.discountMoved)
+			if opts.allBranches {
+				c.patchID = patchID(patch)
+			}
+		}
+		for _, f := range c.files {
+			c.changes += f.changes
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// parseHgDate parses the hgdate format: seconds since the epoch and the
+// offset of the timezone in seconds west of UTC.
+func parseHgDate(s string) (time.Time, error) {
+	var sec, offset int64
+	if _, err := fmt.Sscan(s, &sec, &offset); err != nil {
+		return time.Time{}, fmt.Errorf("bad hg date %q", s)
+	}
+	return time.Unix(sec, 0).In(time.FixedZone("", int(-offset))), nil
+}
+
+// parsePatch returns added and deleted lines per file in a patch in the git
+// extended diff format.
+func parsePatch(patch string) ([]patchFile, error) {
+	var files []patchFile
+	var inHunk bool
+	sc := bufio.NewScanner(strings.NewReader(patch))
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git a/"):
+			path := line[strings.LastIndex(line, " b/")+len(" b/"):]
+			if unq, err := strconv.Unquote(path); err == nil {
+				path = unq
+			}
+			files, inHunk = append(files, patchFile{path: path}), false
+		case len(files) == 0:
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
+			cur := &files[len(files)-1]
+			cur.added = append(cur.added, line[1:])
+		case strings.HasPrefix(line, "-"):
+			cur := &files[len(files)-1]
+			cur.deleted = append(cur.deleted, line[1:])
+		}
+	}
+	return files, sc.Err()
+}
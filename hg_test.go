@@ -0,0 +1,13 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePatchLongLine(t *testing.T) {
+	patch := "diff --git a/min.js b/min.js\n@@ -0,0 +1 @@\n+" + strings.Repeat("x", 2*1024*1024) + "\n"
+	if _, err := parsePatch(patch); err == nil {
+		t.Error("parsePatch of a line over the buffer: no error")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id     INTEGER PRIMARY KEY,
+	time   TIMESTAMP NOT NULL,
+	since  TEXT NOT NULL,
+	author TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS repo_stats (
+	run_id  INTEGER NOT NULL REFERENCES runs(id),
+	path    TEXT NOT NULL,
+	changes INTEGER NOT NULL,
+	authors TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS repo_stats_path ON repo_stats(path);
+`
+
+// history is a SQLite database recording per-repo stats of every run.
+type history struct {
+	db *sql.DB
+}
+
+func openHistory(path string) (*history, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &history{db: db}, nil
+}
+
+func (h *history) Close() error {
+	return h.db.Close()
+}
+
+// record stores the stats of directories analyzed by one run.
+func (h *history) record(t time.Time, since, author string, directories []directory) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO runs (time, since, author) VALUES (?, ?, ?)`, t, since, author)
+	if err != nil {
+		return err
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			return err
+		}
+		authors := strings.Join(dir.authorNames(""), ", ")
+		_, err = tx.Exec(`INSERT INTO repo_stats (run_id, path, changes, authors) VALUES (?, ?, ?, ?)`,
+			runID, redact(abs), dir.changes, authors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
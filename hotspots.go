@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxHotspots is how many files the hotspots report lists.
+const maxHotspots = 20
+
+type hotspot struct {
+	path    string
+	changes int
+	authors []string
+}
+
+// score weighs churn by the number of people changing the file; files many
+// people change a lot are the most contended.
+func (h hotspot) score() int {
+	return h.changes * len(h.authors)
+}
+
+// reportHotspots prints files across all directories ranked by churn and
+// distinct-author count.
+func reportHotspots(w io.Writer, directories []directory) {
+	var hotspots []hotspot
+	for _, dir := range directories {
+		for _, f := range dir.files {
+			hotspots = append(hotspots, hotspot{
+				path:    filepath.Join(displayPath(dir.path), f.path),
+				changes: f.changes,
+				authors: uniq(f.authors),
+			})
+		}
+	}
+	if len(hotspots) == 0 {
+		return
+	}
+
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].score() != hotspots[j].score() {
+			return hotspots[i].score() > hotspots[j].score()
+		}
+		return hotspots[i].path < hotspots[j].path
+	})
+	if len(hotspots) > maxHotspots {
+		hotspots = hotspots[:maxHotspots]
+	}
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "PATH", "CHANGES", "NAUTHORS", "AUTHORS")
+	for _, h := range hotspots {
+		fmt.Fprintf(tw, format, h.path, h.changes, len(h.authors), strings.Join(h.authors, ", "))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// writeICS exports sessions as iCalendar events to the file at path.
+func writeICS(path string, sessions []session) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	const stamp = "20060102T150405Z"
+	now := time.Now().UTC().Format(stamp)
+
+	icsLine(w, "BEGIN:VCALENDAR")
+	icsLine(w, "VERSION:2.0")
+	icsLine(w, "PRODID:-//jreisinger//workedon//EN")
+	for _, s := range sessions {
+		uid := fmt.Sprintf("%x@workedon", sha1.Sum([]byte(s.author+s.start.String())))
+		icsLine(w, "BEGIN:VEVENT")
+		icsLine(w, "UID:"+uid)
+		icsLine(w, "DTSTAMP:"+now)
+		icsLine(w, "DTSTART:"+s.start.UTC().Format(stamp))
+		icsLine(w, "DTEND:"+s.end.UTC().Format(stamp))
+		icsLine(w, "SUMMARY:"+icsEscape(s.author+": "+strings.Join(s.repos, ", ")))
+		icsLine(w, "DESCRIPTION:"+icsEscape(strings.Join(s.subjects(), "\n")))
+		icsLine(w, "END:VEVENT")
+	}
+	icsLine(w, "END:VCALENDAR")
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// icsLine writes a content line folded to at most 75 octets per line as
+// required by RFC 5545.
+func icsLine(w *bufio.Writer, line string) {
+	const max = 75
+	for len(line) > max {
+		cut := max
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
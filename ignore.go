@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ignoreFile lists, in gitignore syntax, files whose changes don't count.
+// It's read from the config directory and from the root of each repo.
+const ignoreFile = ".workedonignore"
+
+// ignorePatterns are read from ignoreFile in the config directory.
+var ignorePatterns []gitignore.Pattern
+
+// readIgnoreFile returns patterns in ignore file at path. A missing file has
+// no patterns.
+func readIgnoreFile(path string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ps []gitignore.Pattern
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		ps = append(ps, parseIgnorePattern(line))
+	}
+	return ps, sc.Err()
+}
+
+// ignoreMatcher returns the matcher of files ignored in repo at path, or nil
+// if there are no ignore patterns. Patterns of the repo, from its config and
+// ignore file, come after those of the config so they can negate them.
+func ignoreMatcher(path string) (gitignore.Matcher, error) {
+	repoPatterns, err := readIgnoreFile(filepath.Join(path, ignoreFile))
+	if err != nil {
+		return nil, err
+	}
+	ps := append([]gitignore.Pattern(nil), ignorePatterns...)
+	ps = append(ps, repoConfigOf(path).excludePatterns()...)
+	ps = append(ps, repoPatterns...)
+	if len(ps) == 0 {
+		return nil, nil
+	}
+	return gitignore.NewMatcher(ps), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+type invoiceItem struct {
+	Repo   string
+	Hours  float64
+	Rate   float64
+	Amount float64
+}
+
+type invoice struct {
+	Month    string
+	Issued   string
+	From     string
+	To       string
+	Currency string
+	Items    []invoiceItem
+	Total    float64
+}
+
+// monthRange returns the start of month (YYYY-MM) and the start of the next
+// one in the -tz timezone.
+func monthRange(month string) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation("2006-01", month, loc)
+	if err != nil {
+		return start, end, fmt.Errorf("want YYYY-MM, got %q", month)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// newInvoice bills hours estimated from sessions at hourly rates per repo
+// from c.
+func newInvoice(month string, sessions []session, c invoiceConfig) (*invoice, error) {
+	hours := make(map[string]time.Duration)
+	for _, rd := range repoDays(sessions) {
+		hours[rd.repo] += rd.spent
+	}
+
+	inv := &invoice{
+		Month:    month,
+		Issued:   time.Now().In(loc).Format("2006-01-02"),
+		From:     c.From,
+		To:       c.To,
+		Currency: c.Currency,
+	}
+	var missing []string
+	for repo, spent := range hours {
+		rate, ok := c.Rates[repo]
+		if !ok {
+			rate, ok = c.Rates[repoName(repo)]
+		}
+		if !ok {
+			rate, ok = c.DefaultRate, c.DefaultRate > 0
+		}
+		if !ok {
+			missing = append(missing, repoName(repo))
+			continue
+		}
+		h := roundHours(spent)
+		item := invoiceItem{Repo: exportName(repo), Hours: h, Rate: rate, Amount: h * rate}
+		inv.Items = append(inv.Items, item)
+		inv.Total += item.Amount
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no hourly rate for %s in invoice.rates", strings.Join(missing, ", "))
+	}
+	sort.Slice(inv.Items, func(i, j int) bool { return inv.Items[i].Repo < inv.Items[j].Repo })
+	return inv, nil
+}
+
+func writeInvoiceMarkdown(w io.Writer, inv *invoice) {
+	fmt.Fprintf(w, "# Invoice %s\n\n", inv.Month)
+	fmt.Fprintf(w, "Issued: %s\n\n", inv.Issued)
+	if inv.From != "" {
+		fmt.Fprintf(w, "**From:**  \n%s\n\n", strings.ReplaceAll(strings.TrimSpace(inv.From), "\n", "  \n"))
+	}
+	if inv.To != "" {
+		fmt.Fprintf(w, "**To:**  \n%s\n\n", strings.ReplaceAll(strings.TrimSpace(inv.To), "\n", "  \n"))
+	}
+	fmt.Fprintf(w, "| Project | Hours | Rate | Amount |\n")
+	fmt.Fprintf(w, "|---|--:|--:|--:|\n")
+	for _, it := range inv.Items {
+		fmt.Fprintf(w, "| %s | %.2f | %.2f %s | %.2f %s |\n", it.Repo, it.Hours, it.Rate, inv.Currency, it.Amount, inv.Currency)
+	}
+	fmt.Fprintf(w, "| **Total** | | | **%.2f %s** |\n", inv.Total, inv.Currency)
+}
+
+var htmlInvoice = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": func(f float64) string { return fmt.Sprintf("%.2f", f) },
+	"lines": func(s string) []string { return strings.Split(strings.TrimSpace(s), "\n") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Month}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 0.3em; }
+td.n, th.n { text-align: right; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Month}}</h1>
+<p>Issued: {{.Issued}}</p>
+{{if .From}}<p><strong>From:</strong><br>{{range lines .From}}{{.}}<br>{{end}}</p>{{end}}
+{{if .To}}<p><strong>To:</strong><br>{{range lines .To}}{{.}}<br>{{end}}</p>{{end}}
+<table>
+<tr><th>Project</th><th class="n">Hours</th><th class="n">Rate</th><th class="n">Amount</th></tr>
+{{range .Items}}<tr><td>{{.Repo}}</td><td class="n">{{money .Hours}}</td><td class="n">{{money .Rate}} {{$.Currency}}</td><td class="n">{{money .Amount}} {{$.Currency}}</td></tr>
+{{end}}<tr><th>Total</th><th></th><th></th><th class="n">{{money .Total}} {{.Currency}}</th></tr>
+</table>
+</body>
+</html>
+`))
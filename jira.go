@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// jiraKeyPattern matches Jira issue keys like PROJ-123, but also words
+	// like SHA-256 or ISO-8601, so keys are only taken from known projects.
+	jiraKeyPattern = `\b[A-Z]{2}[A-Z0-9]*-[1-9][0-9]*\b`
+	// issueNumberPattern matches issue numbers like #123.
+	issueNumberPattern = `#([1-9][0-9]*)\b`
+)
+
+var (
+	jiraKey     = regexp.MustCompile(jiraKeyPattern)
+	issueNumber = regexp.MustCompile(issueNumberPattern)
+)
+
+// inJiraProjects tells whether the issue with key is in one of projects.
+func inJiraProjects(key string, projects []string) bool {
+	project := key[:strings.LastIndex(key, "-")]
+	for _, p := range projects {
+		if p == project {
+			return true
+		}
+	}
+	return false
+}
+
+// jiraProjects returns the Jira project of the repo at path or, if it has
+// none, the projects of config.
+func jiraProjects(path string) []string {
+	if project := repoConfigOf(path).JiraProject; project != "" {
+		return []string{project}
+	}
+	return conf.Jira.Projects
+}
+
+// jiraIssues returns keys of Jira issues mentioned in the message of c that
+// are in jiraProjects of its repo. Bare numbers like #123 are left out, as
+// they usually refer to pull requests.
+func jiraIssues(c commitInfo) []string {
+	projects := jiraProjects(c.repo)
+	var issues []string
+	for _, k := range jiraKey.FindAllString(c.message, -1) {
+		if inJiraProjects(k, projects) {
+			issues = append(issues, k)
+		}
+	}
+	return uniq(issues)
+}
+
+// worklog is time spent on a Jira issue during one day.
+type worklog struct {
+	issue    string
+	started  time.Time
+	spent    time.Duration
+	subjects []string
+	commits  []string // hashes
+}
+
+// jiraWorklogs attributes the time of each session evenly to its commits and
+// the share of each commit evenly to the issues it mentions, so the time
+// logged adds up to the session. Time is aggregated per issue and day. Time
+// of commits already posted to an issue is left out.
+func jiraWorklogs(sessions []session, posted postedCommits) []worklog {
+	type key struct{ issue, day string }
+	logs := make(map[key]*worklog)
+	for _, s := range sessions {
+		share := s.commitShare()
+		for _, c := range s.commits {
+			issues := jiraIssues(c)
+			for _, issue := range issues {
+				if posted["jira"][worklogKey(issue, c.hash)] {
+					continue
+				}
+				k := key{issue, c.when.In(loc).Format("2006-01-02")}
+				wl, ok := logs[k]
+				if !ok {
+					wl = &worklog{issue: issue, started: c.when}
+					logs[k] = wl
+				}
+				if c.when.Before(wl.started) {
+					wl.started = c.when
+				}
+				wl.spent += share / time.Duration(len(issues))
+				wl.subjects = append(wl.subjects, c.subject)
+				wl.commits = append(wl.commits, c.hash)
+			}
+		}
+	}
+
+	var worklogs []worklog
+	for _, wl := range logs {
+		if wl.spent < time.Minute {
+			wl.spent = time.Minute // Jira rejects zero time spent
+		}
+		wl.subjects = uniq(wl.subjects)
+		worklogs = append(worklogs, *wl)
+	}
+	sort.Slice(worklogs, func(i, j int) bool { return worklogs[i].started.Before(worklogs[j].started) })
+	return worklogs
+}
+
+// postJiraWorklogs adds worklogs to their Jira issues and marks their commits
+// as posted to them. An issue that can't be logged to doesn't stop the
+// others. If dryRun is true it only prints what would be posted.
+func postJiraWorklogs(worklogs []worklog, posted postedCommits, dryRun bool) error {
+	if dryRun {
+		printWorklogs(os.Stdout, worklogs)
+		return nil
+	}
+
+	c := conf.Jira
+	if c.URL == "" || c.Token == "" {
+		return fmt.Errorf("jira.url and jira.token must be set in config")
+	}
+	auth := jiraAuth(c)
+	var failed []string
+	for _, wl := range worklogs {
+		url := fmt.Sprintf("%s/rest/api/2/issue/%s/worklog", strings.TrimSuffix(c.URL, "/"), wl.issue)
+		body := map[string]interface{}{
+			"started":          wl.started.Format("2006-01-02T15:04:05.000-0700"),
+			"timeSpentSeconds": int(wl.spent.Seconds()),
+			"comment":          strings.Join(wl.subjects, "\n"),
+		}
+		if err := postJSON(url, body, auth); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", wl.issue, err))
+			continue
+		}
+		var keys []string
+		for _, h := range wl.commits {
+			keys = append(keys, worklogKey(wl.issue, h))
+		}
+		posted.mark("jira", keys)
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// worklogKey identifies the time of commit hash posted to issue, as a commit
+// may mention more issues.
+func worklogKey(issue, hash string) string {
+	return issue + " " + hash
+}
+
+func printWorklogs(w io.Writer, worklogs []worklog) {
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "ISSUE", "STARTED", "SPENT", "COMMITS")
+	for _, wl := range worklogs {
+		fmt.Fprintf(tw, format, wl.issue, wl.started.In(loc).Format("2006-01-02 15:04"),
+			wl.spent.Round(time.Minute), strings.Join(wl.subjects, "; "))
+	}
+	tw.Flush()
+}
+
+// jiraAuth returns a function setting credentials of c on requests.
+func jiraAuth(c jiraConfig) func(*http.Request) {
+	return func(r *http.Request) {
+		if c.User != "" { // Jira Cloud
+			r.SetBasicAuth(c.User, c.Token)
+		} else { // personal access token on Jira Server
+			r.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+	}
+}
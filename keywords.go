@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// reportKeywords prints the n most frequent keywords of commit subjects in
+// each repo and in all of them, a quick summary of what the work was about.
+func reportKeywords(w io.Writer, directories []directory, n int) {
+	if len(directories) == 0 {
+		return
+	}
+	cell := func(subjects []string) string {
+		var words []string
+		for _, k := range subjectKeywords(subjects) {
+			if len(words) == n {
+				break
+			}
+			words = append(words, fmt.Sprintf("%s (%d)", k.word, k.count))
+		}
+		return strings.Join(words, ", ")
+	}
+
+	sortDirectories(directories)
+	tw := newTableWriter(w)
+	fmt.Fprintln(tw, "PATH\tCOMMITS\tKEYWORDS")
+	var all []string
+	for _, dir := range directories {
+		var subjects []string
+		for _, c := range dir.commits {
+			subjects = append(subjects, c.subject)
+		}
+		all = append(all, subjects...)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", displayPath(dir.path), formatInt(len(subjects)), cell(subjects))
+	}
+	fmt.Fprintf(tw, "%s\t%s\t%s\n", "TOTAL", formatInt(len(all)), cell(all))
+	tw.Flush()
+}
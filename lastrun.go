@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// lastRun is what we remember about a repo from the previous invocation.
+type lastRun struct {
+	Head string    `json:"head"`
+	Time time.Time `json:"time"`
+}
+
+// lastRunFile returns the path of the file recording the last analyzed tip
+// per repo.
+func lastRunFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workedon", "last-run.json"), nil
+}
+
+// loadLastRuns returns the last runs keyed by absolute repo path. A missing
+// file is not an error.
+func loadLastRuns() (map[string]lastRun, error) {
+	runs := make(map[string]lastRun)
+
+	name, err := lastRunFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return runs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func saveLastRuns(runs map[string]lastRun) error {
+	name, err := lastRunFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// commitsSince returns an iterator over commits reachable from HEAD but not
+// from tip, i.e. commits that appeared since tip was recorded.
+func commitsSince(repo *git.Repository, tip plumbing.Hash) (object.CommitIter, error) {
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	old, err := repo.CommitObject(tip)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(old, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return object.NewCommitPreorderIter(head, seen, nil), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// reportLeaderboard ranks authors other than bots by their changes, commits
+// and the number of repos they committed to across directories.
+func reportLeaderboard(w io.Writer, directories []directory) {
+	type entry struct {
+		author                 string
+		changes, commits, reps int
+	}
+	perAuthor := make(map[string]*entry)
+	for _, dir := range directories {
+		touched := make(map[string]bool)
+		for _, c := range dir.commits {
+			if isBot(c) {
+				continue
+			}
+			e := perAuthor[c.author]
+			if e == nil {
+				e = &entry{author: c.author}
+				perAuthor[c.author] = e
+			}
+			e.changes += c.changes
+			e.commits++
+			if !touched[c.author] {
+				touched[c.author] = true
+				e.reps++
+			}
+		}
+	}
+	var entries []*entry
+	for _, e := range perAuthor {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case a.changes != b.changes:
+			return a.changes > b.changes
+		case a.commits != b.commits:
+			return a.commits > b.commits
+		case a.reps != b.reps:
+			return a.reps > b.reps
+		}
+		return a.author < b.author
+	})
+
+	t := table{header: []string{"RANK", "AUTHOR", "CHANGES", "COMMITS", "REPOS"}, authorsCol: 1}
+	for i, e := range entries {
+		style := ""
+		if i < 3 {
+			style = bold
+		}
+		t.add(style, fmt.Sprint(i+1), e.author, fmt.Sprint(e.changes), fmt.Sprint(e.commits), fmt.Sprint(e.reps))
+	}
+	t.write(w)
+}
@@ -0,0 +1,199 @@
+//go:build libgit2
+
+package main
+
+import (
+	"time"
+
+	git2go "github.com/libgit2/git2go/v34"
+)
+
+func init() {
+	gitBackends["libgit2"] = func(path string) (vcs, error) { return openLibgit2Repo(path) }
+}
+
+// libgit2Repo is a git repo read by libgit2, which computes changes much
+// faster than go-git. It needs cgo and libgit2 1.5.
+type libgit2Repo struct {
+	repo *git2go.Repository
+}
+
+func openLibgit2Repo(path string) (libgit2Repo, error) {
+	repo, err := git2go.OpenRepository(path)
+	return libgit2Repo{repo}, err
+}
+
+func (r libgit2Repo) head() (string, error) {
+	ref, err := r.repo.Head()
+	if err != nil {
+		return "", err
+	}
+	defer ref.Free()
+	return ref.Target().String(), nil
+}
+
+// pull uses the git command as libgit2 can't merge on pull.
+func (r libgit2Repo) pull() error {
+	return gitCLIRepo{path: r.repo.Workdir()}.pull()
+}
+
+func (r libgit2Repo) fetchRemotes() (string, error) {
+	return gitCLIRepo{path: r.repo.Workdir()}.fetchRemotes()
+}
+
+func (r libgit2Repo) shallowStart() (time.Time, error) {
+	return gitCLIRepo{path: r.repo.Workdir()}.shallowStart()
+}
+
+func (r libgit2Repo) deepen(since time.Time) error {
+	return gitCLIRepo{path: r.repo.Workdir()}.deepen(since)
+}
+
+func (r libgit2Repo) log(opts options, lastTip string) ([]commitInfo, error) {
+	walk, err := r.repo.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+	switch {
+	case opts.allBranches:
+		err = walk.PushGlob("refs/heads/*")
+	case opts.rev != "":
+		var obj *git2go.Object
+		if obj, err = r.repo.RevparseSingle(opts.rev); err == nil {
+			err = walk.Push(obj.Id())
+			obj.Free()
+		}
+	default:
+		err = walk.PushHead()
+	}
+	if err != nil {
+		return nil, err
+	}
+	walk.Sorting(git2go.SortTime)
+	if lastTip != "" {
+		oid, err := git2go.NewOid(lastTip)
+		if err == nil {
+			err = walk.Hide(oid)
+		}
+		if err != nil {
+			// History was rewritten, fall back to the time window.
+			lastTip = ""
+		}
+	}
+	var since, until, stop time.Time
+	if lastTip == "" {
+		since, until = opts.span()
+		stop = since.Add(-stopSlack)
+	}
+
+	var commits []commitInfo
+	var iterErr error
+	err = walk.Iterate(func(commit *git2go.Commit) bool {
+		defer commit.Free()
+
+		if !stop.IsZero() && commit.Committer().When.Before(stop) {
+			return false
+		}
+		author := commit.Author()
+		when := commit.Committer().When
+		if opts.authorDate {
+			when = author.When
+		}
+		if !since.IsZero() && (when.Before(since) || when.After(until)) {
+			return true
+		}
+
+		c := commitInfo{
+			hash:    commit.Id().String(),
+			author:  author.Name,
+			email:   author.Email,
+			when:    when,
+			subject: commit.Summary(),
+			message: commit.Message(),
+			signoff: signedOffByAuthor(commit.Message(), author.Name, author.Email),
+		}
+		if opts.verifySignatures {
+			if sig, _, err := commit.ExtractSignature(); err == nil && sig != "" {
+				c.signed = verifyCommit(r.repo.Path(), c.hash)
+			}
+		}
+		if !opts.selects(c) {
+			return true
+		}
+
+		if commit.ParentCount() <= 1 { // merges bring changes of other commits
+			c.files, c.patchID, iterErr = r.changes(commit, opts)
+			if iterErr != nil {
+				return false
+			}
+		}
+		for _, f := range c.files {
+			c.changes += f.changes
+		}
+		commits = append(commits, c)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, iterErr
+}
+
+// changes returns added and deleted lines per file of commit compared to its
+// first parent and, with -all-branches, its patch ID. Only files kept by opts
+// are diffed.
+func (r libgit2Repo) changes(commit *git2go.Commit, opts options) ([]fileChange, string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, "", err
+	}
+	defer tree.Free()
+	var parentTree *git2go.Tree
+	if commit.ParentCount() > 0 {
+		parent := commit.Parent(0)
+		defer parent.Free()
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return nil, "", err
+		}
+		defer parentTree.Free()
+	}
+
+	var diffOpts *git2go.DiffOptions
+	if globs := opts.pathGlobs(); globs != nil {
+		o, err := git2go.DefaultDiffOptions()
+		if err != nil {
+			return nil, "", err
+		}
+		o.Pathspec = globs
+		diffOpts = &o
+	}
+	diff, err := r.repo.DiffTreeToTree(parentTree, tree, diffOpts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer diff.Free()
+
+	var files []patchFile
+	err = diff.ForEach(func(delta git2go.DiffDelta, _ float64) (git2go.DiffForEachHunkCallback, error) {
+		i := len(files)
+		files = append(files, patchFile{path: delta.NewFile.Path})
+		return func(git2go.DiffHunk) (git2go.DiffForEachLineCallback, error) {
+			return func(line git2go.DiffLine) error {
+				switch line.Origin {
+				case git2go.DiffLineAddition:
+					files[i].added = append(files[i].added, line.Content)
+				case git2go.DiffLineDeletion:
+					files[i].deleted = append(files[i].deleted, line.Content)
+				}
+				return nil
+			}, nil
+		}, nil
+	}, git2go.DiffDetailLines)
+	var id string
+	if opts.allBranches {
+		id = patchID(files)
+	}
+	return fileChanges(files, opts.discountMoved), id, err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// numbers prints numbers in the -locale, or is nil to print them plain.
+var numbers *message.Printer
+
+// setLocale makes numbers print in the locale named by BCP 47 tag like de
+// or en-US.
+func setLocale(tag string) error {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return err
+	}
+	numbers = message.NewPrinter(t)
+	return nil
+}
+
+// formatInt returns n with the thousands separators of the -locale.
+func formatInt(n int) string {
+	if numbers == nil {
+		return fmt.Sprint(n)
+	}
+	return numbers.Sprintf("%d", n)
+}
+
+// formatFloat returns f with prec decimal places and the separators of the
+// -locale.
+func formatFloat(f float64, prec int) string {
+	if numbers == nil {
+		return fmt.Sprintf("%.*f", prec, f)
+	}
+	return numbers.Sprintf("%.*f", prec, f)
+}
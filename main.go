@@ -1,29 +1,58 @@
 package main
 
 import (
-	"errors"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
-	"text/tabwriter"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"golang.org/x/term"
 )
 
 type directory struct {
 	path    string
 	changes int
 	authors []string
-	repo    *git.Repository
+	repo    vcs
 	files   []file
+	commits []commitInfo
+	stale   bool // pulling failed
+
+	// detached is the default remote branch analyzed instead of the
+	// detached HEAD.
+	detached string
+	// branch is the default branch of a remote analyzed instead of HEAD
+	// with -all-remotes.
+	branch string
+
+	// prs are pull or merge requests, or changes, in the window, set with
+	// -prs for repos on GitHub, GitLab or Gerrit.
+	prs *prActivity
+
+	// previous is the repo in the preceding window of the same length,
+	// set with -delta.
+	previous *directory
+}
+
+// countCommits returns the number of commits for which f returns true.
+func (dir directory) countCommits(f func(commitInfo) bool) int {
+	var n int
+	for _, c := range dir.commits {
+		if f(c) {
+			n++
+		}
+	}
+	return n
 }
 
 type file struct {
@@ -32,34 +61,749 @@ type file struct {
 	authors []string
 }
 
+type commitInfo struct {
+	repo    string // path of the directory
+	hash    string
+	author  string // name, with email if -show-emails is set
+	email   string
+	when    time.Time
+	subject string
+	message string
+	changes int
+	signed  bool   // has a verified signature
+	signoff bool   // signed off by the author
+	patchID string // only with -all-branches
+	files   []fileChange
+
+	// generated is set with -generated if the changes are mostly in
+	// generated files.
+	generated bool
+}
+
+// touches tells whether c changed the file at path.
+func (c commitInfo) touches(path string) bool {
+	for _, f := range c.files {
+		if f.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// fileChange is a file changed by a commit.
+type fileChange struct {
+	path    string
+	changes int
+	net     int // added minus deleted lines
+}
+
 var (
-	author = flag.String("author", "", "only changes by `this` author")
-	days   = flag.Int("days", 7, "changes made in last `n` days")
-	files  = flag.Bool("files", false, "changes per file (default is per repo)")
-	pull   = flag.Bool("pull", false, "pull the repo before parsing its logs")
+	author            = flag.String("author", "", "only changes by `this` author")
+	grepFlag          = flag.String("grep", "", "only commits with messages matching `regexp`")
+	invertGrep        = flag.Bool("invert-grep", false, "only commits with messages not matching -grep")
+	extFlag           = flag.String("ext", "", "only changes of files with comma-separated `extensions` like go,md")
+	pathsFlag         = flag.String("path", "", "only changes of files under comma-separated `paths` relative to repo root")
+	discountMoved     = flag.Bool("discount-moved", false, "don't count lines moved to another place in the same commit, like when reorganizing files")
+	metric            = flag.String("metric", "churn", "show `metric` churn (added plus deleted lines) or also net (added minus deleted lines)")
+	minCommitChanges  = flag.Int("min-commit-changes", 0, "only commits with at least `n` changes, like to leave out typo fixes")
+	maxCommitChanges  = flag.Int("max-commit-changes", 0, "only commits with at most `n` changes, like to leave out vendor drops, 0 for no limit")
+	includeBots       = flag.Bool("include-bots", false, "count commits of bots like dependabot, renovate and github-actions, left out by default")
+	generatedFlag     = flag.String("generated", "", "`flag` commits mostly changing generated files like lockfiles, go.sum, mocks or protobuf code with a GENERATED column of their share of changes, or `exclude` them")
+	cancelRevertsFlag = flag.Bool("cancel-reverts", false, "leave out reverts together with the commits they revert")
+	compareAuthors    = flag.String("compare-authors", "", "show changes and commits per repo of comma-separated `authors` side by side")
+	redactFlag        = flag.String("redact", "", "hide `what` in all outputs: paths of repos, shown as codenames from config or short hashes")
+	leaderboard       = flag.Bool("leaderboard", false, "rank authors other than bots by changes, commits and repos they committed to")
+	matrix            = flag.String("matrix", "", "print changes with authors as columns and repos as rows in `format` csv or html")
+	groupBy           = flag.String("group-by", "", "show a table of changes for each `period`: week")
+	bars              = flag.Bool("bars", false, "show bars proportional to the changes of each row")
+	delta             = flag.Bool("delta", false, "show the change of each row's changes since the preceding window of the same length in DELTA column")
+	why               = flag.String("why", "", "add a WHY column with the subject of the `largest` commit or the most frequent subject keywords of each row")
+	prs               = flag.Bool("prs", false, "add OPENED, REVIEWED and MERGED columns with pull requests of the -author in the window from GitHub, merge requests from GitLab or changes from Gerrit, and REVIEWS with review comments")
+	allRemotes        = flag.Bool("all-remotes", false, "fetch all remotes of git repos, like origin and upstream, and analyze the default branch with the newest commit")
+	deepen            = flag.Bool("deepen", false, "fetch older history of shallow clones of git repos until it reaches back to the start of the window")
+	allBranches       = flag.Bool("all-branches", false, "changes on all local branches, counting changes cherry-picked between them once")
+	days              = flag.Int("days", 7, "changes made in last `n` days")
+	files             = flag.Bool("files", false, "changes per file (default is per repo)")
+	sortBy            = flag.String("sort", "changes", "order rows by `key` changes or path")
+	maxAuthors        = flag.Int("max-authors", 5, "list at most `k` authors with the most changes per row, 0 for all")
+	showEmails        = flag.Bool("show-emails", false, "show authors with their emails")
+	pathStyle         = flag.String("path-style", "", "show repo paths as `style` abs, rel (to working directory) or name (default as given)")
+	percentOf         = flag.String("percent-of", "changes", "show rows' share of total `base` changes, commits or none")
+	percentPrecision  = flag.Int("percent-precision", 0, "show percentages with `n` decimal places")
+	locale            = flag.String("locale", "", "show changes and percentages with separators of `locale` like de or en-US")
+	colorFlag         = flag.String("color", "auto", "color the report `when`: always, never or auto if stdout is a terminal")
+	reverse           = flag.Bool("reverse", false, "reverse the order of rows")
+	by                = flag.String("by", "repo", "group changes by `what`: repo, ticket referenced in commit messages, conventional commit type or category of files: source, tests, docs, config or build")
+	ticketStatus      = flag.Bool("ticket-status", false, "with -by ticket, also list tickets advanced in the window by project with titles and statuses from Jira or GitHub Issues")
+	backend           = flag.String("backend", "go-git", "read git repos with `library` go-git, the git command, which is faster in big repos, or libgit2")
+	maxMemory         = flag.Int("max-memory", 0, "analyze one repo at a time while the heap is over `MiB` megabytes, 0 for no limit")
+	objectCache       = flag.Int("object-cache", 0, "cache at most `MiB` megabytes of git objects per repo with go-git (default 96)")
+	dedup             = flag.Bool("dedup", false, "count commits found in several repos, like forks or mirrors, only once")
+	pull              = flag.Bool("pull", false, "pull the repo before parsing its logs")
+	failOnPullError   = flag.Bool("fail-on-pull-error", false, "exit if pulling a repo fails instead of reporting it as stale")
+	db                = flag.String("db", "", "record per-repo stats of this run in SQLite database at `path`")
+	prom              = flag.String("prometheus-textfile", "", "export changes and commits per repo and author to node_exporter textfile at `path`")
+	statsd            = flag.String("statsd", "", "push total and per-repo changes and scan duration to StatsD at `host:port`")
+	dateFlag          = flag.String("date", "committer", "select and bucket commits by `author` or committer date")
+	since             = flag.String("since", "", "changes made since `when`: a duration like 72h or last-run (overrides -days)")
+	webhook           = flag.String("webhook", "", "post the report to Slack or Mattermost incoming webhook at `url`")
+	webhookSummary    = flag.Bool("webhook-summary", false, "post only a summary to -webhook")
+	email             = flag.String("email", "", "mail the report to comma-separated `addresses` using smtp settings from config")
+	emailHTML         = flag.Bool("email-html", false, "mail the report as HTML instead of plain text")
+	maxGap            = flag.Duration("max-gap", 2*time.Hour, "longest pause between commits of one work session")
+	estimateHours     = flag.Bool("estimate-hours", false, "estimate hours worked per repo and day from work sessions")
+	icsFile           = flag.String("ics", "", "export work sessions inferred from commit times as iCalendar `file`")
+	timetrack         = flag.String("timetrack", "", "create time entries from work sessions in `service` toggl or clockify")
+	jiraWorklog       = flag.Bool("jira-worklog", false, "log time spent on Jira issues mentioned in commit messages")
+	harvest           = flag.Bool("harvest", false, "create daily Harvest time entries for repos mapped to projects in config")
+	dryRun            = flag.Bool("dry-run", false, "only show what would be sent to time tracking services, Jira and with -narrate")
+	configFile        = flag.String("config", "", "read config from `file` (default workedon/config.yaml in user config directory)")
+	signed            = flag.Bool("signed", false, "verify commit signatures and show verified/all commits in SIGNED column")
+	signedOnly        = flag.Bool("signed-only", false, "only commits with verified GPG or SSH signatures")
+	signoff           = flag.Bool("signoff", false, "show commits signed off by their author/all commits in SIGNOFF column")
+	signoffOnly       = flag.Bool("signoff-only", false, "only commits with a Signed-off-by trailer of their author")
+	workHoursFlag     = flag.String("work-hours", "", "also show share of commits outside `HH:MM-HH:MM` on weekdays and on weekends per author")
+	busiest           = flag.Bool("busiest", false, "show histograms of commits by weekday and hour of day")
+	streaksFlag       = flag.Bool("streaks", false, "show current and longest streaks of days with commits overall and per repo")
+	ownership         = flag.Bool("ownership", false, "show each author's share of changes and bus factor per repo and top-level directory")
+	coupling          = flag.Bool("coupling", false, "show files of each repo that most often change in the same commits")
+	testRatio         = flag.Bool("test-ratio", false, "add a TESTS/CODE column with changes of test files per change of other source code")
+	keywordsFlag      = flag.Int("keywords", 0, "list the `n` most frequent keywords of commit subjects per repo and overall")
+	narrateFlag       = flag.Bool("narrate", false, "summarize the work in the first person by sending commit subjects and most changed files to a language model, a local Ollama unless set in config")
+	hotspots          = flag.Bool("hotspots", false, "rank files across all repos by churn and number of authors")
+	treeOn            = flag.Bool("tree", false, "show changes as a tree of directories containing the repos, rolled up at each level")
+	output            = flag.String("output", "table", "report `format`: table or jsonl streaming a JSON object per repo")
+	porcelain         = flag.Bool("porcelain", false, "print tab-separated records in a format stable for scripts")
+	chartFile         = flag.String("chart", "", "also draw changes per repo and author as a bar chart to `file` ending with .png or .svg")
+	outFile           = flag.String("o", "", "write the report to `file` and only a summary to stdout")
+	noPager           = flag.Bool("no-pager", false, "don't show long reports in $PAGER")
+	tuiOn             = flag.Bool("tui", false, "explore the results interactively")
+	watchOn           = flag.Bool("watch", false, "keep re-rendering the report as new commits land")
+	addr              = flag.String("addr", ":8080", "serve: listen on `address`")
+	month             = flag.String("month", "", "invoice: bill work done in `YYYY-MM` (default last month)")
+	invoiceHTML       = flag.Bool("html", false, "invoice: write HTML instead of Markdown")
+	months            = flag.Int("months", 6, "trend: show the last `n` months, by month or by week with -group-by week")
+	rolling           = flag.Int("rolling", 1, "trend: show the average of `n` periods up to each, like 4 with -group-by week")
+	verify            = flag.Bool("verify", false, "cross-check changes of a sample of commits per repo against git log --numstat")
+	tz                = flag.String("tz", "", "bucket commits into days and hours in `timezone` like Europe/Bratislava (default local)")
+	every             = flag.Duration("every", 24*time.Hour, "daemon: run every `interval`")
 )
 
+// loc is the timezone in which commits are bucketed into days and hours.
+var loc = time.Local
+
+// options control which changes are analyzed.
+type options struct {
+	author        string
+	pull          bool
+	window        time.Duration
+	until         time.Time // zero means now
+	sinceLastRun  bool
+	authorDate    bool              // use author instead of committer date
+	showEmails    bool              // identify authors by name and email
+	exts          []string          // only files with these extensions
+	paths         []string          // only files under these paths in repos
+	discountMoved bool              // don't count lines moved within a commit
+	ignore        gitignore.Matcher // files whose changes don't count, set per repo
+
+	minCommitChanges int    // only commits with at least this many changes
+	maxCommitChanges int    // only commits with at most this many changes, 0 for no limit
+	cancelReverts    bool   // drop reverts together with the reverted commits
+	generated        string // flag or exclude commits of generated churn, empty to count them as others
+	allBranches      bool   // walk all local branches instead of HEAD
+	allRemotes       bool   // fetch all remotes and walk the freshest default branch instead of HEAD
+	deepen           bool   // fetch history of shallow clones back to the start of the window
+	rev              string // walk commits reachable from rev instead of HEAD
+
+	// emit is called, if set, with each directory that has some changes
+	// as soon as it's analyzed.
+	emit func(directory)
+
+	// quiet is set for another pass over repos already analyzed, which
+	// neither logs their problems again nor counts them as opened or failed.
+	quiet bool
+
+	verifySignatures bool
+	signedOnly       bool // only commits with verified signatures
+	signoffOnly      bool // only commits signed off by their author
+
+	grep       *regexp.Regexp // only commits with matching messages
+	invertGrep bool           // only commits with messages not matching grep
+
+	includeBots bool // don't leave out commits of bots like dependabot
+
+	// optOutMarker in a commit message leaves the commit out.
+	optOutMarker string
+}
+
+// commands are the subcommands. All of them share the flags; those that
+// apply to only one command say so.
+var commands = []struct {
+	name, args, help string
+}{
+	{"report", "repo [repo ...]", "show changes (default)"},
+	{"pull", "repo [repo ...]", "pull repos"},
+	{"sync", "repo [repo ...]", "pull repos and publish their changes without showing them"},
+	{"standup", "repo [repo ...]", "list commits since the start of the previous workday"},
+	{"heatmap", "repo [repo ...] > heatmap.svg", "draw a yearly heatmap of commits per day as SVG"},
+	{"snapshot", "repo [repo ...] > snapshot.json", "save changes for a later diff"},
+	{"diff", "snapshot.json [repo ...]", "compare changes with a snapshot"},
+	{"serve", "repo [repo ...]", "serve JSON reports and a dashboard"},
+	{"daemon", "repo [repo ...]", "report and publish changes periodically"},
+	{"invoice", "repo [repo ...]", "bill work done in a month"},
+	{"trend", "-db path [repo ...]", "show changes per repo and month recorded in the history database"},
+}
+
+func isCommand(name string) bool {
+	for _, c := range commands {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
 	log.SetFlags(0)
 	log.SetPrefix(os.Args[0] + ": ")
 
 	flag.Usage = func() {
 		desc := "What you (or others) have worked on."
-		fmt.Fprintf(flag.CommandLine.Output(), "%s\n\n%s [flags] repo [repo ...]\n", desc, os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n\n", desc)
+		tw := newTableWriter(flag.CommandLine.Output())
+		for _, c := range commands {
+			fmt.Fprintf(tw, "%s %s [flags] %s\t%s\n", os.Args[0], c.name, c.args, c.help)
+		}
+		tw.Flush()
 		flag.PrintDefaults()
 	}
 
-	flag.Parse()
+	cmd := "report"
+	args := os.Args[1:]
+	if len(args) > 0 && isCommand(args[0]) {
+		cmd, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
 
-	if len(flag.Args()) == 0 {
+	if len(flag.Args()) == 0 && cmd != "trend" {
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	var err error
+	conf, err = loadConfig(*configFile)
+	if err != nil {
+		log.Fatalf("loading config: %v", err)
+	}
+	if dir := configDir(*configFile); dir != "" {
+		if ignorePatterns, err = readIgnoreFile(filepath.Join(dir, ignoreFile)); err != nil {
+			log.Fatalf("loading ignore file: %v", err)
+		}
+	}
+
+	opts := options{
+		author:        *author,
+		pull:          *pull,
+		window:        time.Hour * 24 * time.Duration(*days),
+		sinceLastRun:  *since == "last-run",
+		authorDate:    *dateFlag == "author",
+		showEmails:    *showEmails,
+		exts:          splitList(*extFlag, "."),
+		paths:         splitList(slashPath(*pathsFlag), "/"),
+		discountMoved: *discountMoved,
+
+		minCommitChanges: *minCommitChanges,
+		maxCommitChanges: *maxCommitChanges,
+		cancelReverts:    *cancelRevertsFlag,
+		generated:        *generatedFlag,
+		allBranches:      *allBranches,
+		allRemotes:       *allRemotes,
+		deepen:           *deepen,
+
+		verifySignatures: *signed || *signedOnly,
+		signedOnly:       *signedOnly,
+		signoffOnly:      *signoffOnly,
+		invertGrep:       *invertGrep,
+		optOutMarker:     conf.optOutMarker(),
+		includeBots:      *includeBots,
+	}
+	if *grepFlag != "" {
+		if opts.grep, err = regexp.Compile(*grepFlag); err != nil {
+			log.Fatalf("-grep: %v", err)
+		}
+	} else if *invertGrep {
+		log.Fatal("-invert-grep: needs -grep")
+	}
+	if *sortBy != "changes" && *sortBy != "path" {
+		log.Fatalf("-sort: want changes or path, got %q", *sortBy)
+	}
+	if *pathStyle != "" && *pathStyle != "abs" && *pathStyle != "rel" && *pathStyle != "name" {
+		log.Fatalf("-path-style: want abs, rel or name, got %q", *pathStyle)
+	}
+	if isTerminal(os.Stdout) {
+		termWidth, _, _ = term.GetSize(int(os.Stdout.Fd()))
+	}
+	if colorOn, err = useColor(*colorFlag); err != nil {
+		log.Fatalf("-color: %v", err)
+	}
+	if gitBackends[*backend] == nil {
+		log.Fatalf("-backend: want go-git, git or libgit2 (if built with -tags libgit2), got %q", *backend)
+	}
+	if *output != "table" && *output != "jsonl" {
+		log.Fatalf("-output: want table or jsonl, got %q", *output)
+	}
+	if *percentOf != "changes" && *percentOf != "commits" && *percentOf != "none" {
+		log.Fatalf("-percent-of: want changes, commits or none, got %q", *percentOf)
+	}
+	if ext := strings.ToLower(filepath.Ext(*chartFile)); *chartFile != "" && ext != ".png" && ext != ".svg" {
+		log.Fatalf("-chart: want a .png or .svg file, got %s", *chartFile)
+	}
+	if *compareAuthors != "" && (len(splitList(*compareAuthors, "")) < 2 || *author != "") {
+		log.Fatal("-compare-authors: want at least two authors and no -author")
+	}
+	if *matrix != "" && *matrix != "csv" && *matrix != "html" {
+		log.Fatalf("-matrix: want csv or html, got %q", *matrix)
+	}
+	if *redactFlag != "" && *redactFlag != "paths" {
+		log.Fatalf("-redact: want paths, got %q", *redactFlag)
+	}
+	if *groupBy != "" && *groupBy != "week" {
+		log.Fatalf("-group-by: want week, got %q", *groupBy)
+	}
+	if *delta && (opts.sinceLastRun || *groupBy != "") {
+		log.Fatal("-delta: can't compare with the preceding window with -since last-run or -group-by")
+	}
+	if *generatedFlag != "" && *generatedFlag != "flag" && *generatedFlag != "exclude" {
+		log.Fatalf("-generated: want flag or exclude, got %q", *generatedFlag)
+	}
+	if *keywordsFlag < 0 {
+		log.Fatalf("-keywords: want n >= 0, got %d", *keywordsFlag)
+	}
+	if *why != "" && *why != "largest" && *why != "keywords" {
+		log.Fatalf("-why: want largest or keywords, got %q", *why)
+	}
+	if *metric != "churn" && *metric != "net" {
+		log.Fatalf("-metric: want churn or net, got %q", *metric)
+	}
+	if *locale != "" {
+		if err := setLocale(*locale); err != nil {
+			log.Fatalf("-locale: %v", err)
+		}
+	}
+	if *percentPrecision < 0 {
+		log.Fatalf("-percent-precision: want n >= 0, got %d", *percentPrecision)
+	}
+	if *verify && *discountMoved {
+		log.Fatal("-verify: git log --numstat counts moved lines, can't cross-check with -discount-moved")
+	}
+	if *dateFlag != "author" && *dateFlag != "committer" {
+		log.Fatalf("-date: want author or committer, got %q", *dateFlag)
+	}
+	if *by != "repo" && *by != "ticket" && *by != "type" && *by != "category" {
+		log.Fatalf("-by: want repo, ticket, type or category, got %q", *by)
+	}
+	if *since != "" && !opts.sinceLastRun {
+		d, err := time.ParseDuration(*since)
+		if err != nil {
+			log.Fatalf("-since: want duration or last-run, got %q", *since)
+		}
+		opts.window = d
+	}
+
+	if *tz != "" {
+		loc, err = time.LoadLocation(*tz)
+		if err != nil {
+			log.Fatalf("-tz: %v", err)
+		}
+	}
+
+	var wh workHours
+	if *workHoursFlag != "" {
+		wh, err = parseWorkHours(*workHoursFlag)
+		if err != nil {
+			log.Fatalf("-work-hours: %v", err)
+		}
+	}
+
+	switch cmd {
+	case "pull":
+		os.Exit(pullRepos(flag.Args()))
+	case "sync":
+		opts.pull = true
+		start := time.Now()
+		directories := scan(flag.Args(), opts)
+		publish(directories, opts, time.Since(start))
+		os.Exit(exitCode(directories))
+	case "standup":
+		opts.window, opts.sinceLastRun = time.Since(previousWorkday(time.Now().In(loc))), false
+		reportStandup(os.Stdout, analyze(flag.Args(), opts, nil))
+	case "heatmap":
+		now := time.Now().In(loc)
+		opts.window, opts.sinceLastRun = now.Sub(heatmapStart(now)), false
+		writeHeatmap(os.Stdout, analyze(flag.Args(), opts, nil), now)
+	case "snapshot":
+		directories := scan(flag.Args(), opts)
+		if err := writeSnapshot(os.Stdout, directories); err != nil {
+			log.Fatalf("writing snapshot: %v", err)
+		}
+	case "diff":
+		snap, err := readSnapshot(flag.Arg(0))
+		if err != nil {
+			log.Fatalf("reading snapshot: %v", err)
+		}
+		paths := flag.Args()[1:]
+		if len(paths) == 0 {
+			for _, r := range snap.Repos {
+				paths = append(paths, r.Path)
+			}
+		}
+		directories := scan(paths, opts)
+		reportDiff(snap, directories)
+	case "serve":
+		log.Fatal(serve(*addr, flag.Args(), opts))
+	case "daemon":
+		daemon(*every, flag.Args(), opts)
+	case "invoice":
+		m := *month
+		if m == "" {
+			// The first of the month, as on March 31 a month ago is March 3.
+			y, mon, _ := time.Now().In(loc).Date()
+			m = time.Date(y, mon-1, 1, 0, 0, 0, 0, loc).Format("2006-01")
+		}
+		start, end, err := monthRange(m)
+		if err != nil {
+			log.Fatalf("-month: %v", err)
+		}
+		opts.until, opts.window, opts.sinceLastRun = end, end.Sub(start), false
+		directories := analyze(flag.Args(), opts, nil)
+		inv, err := newInvoice(m, workSessions(directories, *maxGap, sessionLead), conf.Invoice)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *invoiceHTML {
+			if err := htmlInvoice.Execute(os.Stdout, inv); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			writeInvoiceMarkdown(os.Stdout, inv)
+		}
+	case "trend":
+		if *db == "" {
+			log.Fatal("trend: needs -db")
+		}
+		if *months < 1 {
+			log.Fatalf("-months: want n >= 1, got %d", *months)
+		}
+		if *rolling < 1 {
+			log.Fatalf("-rolling: want n >= 1, got %d", *rolling)
+		}
+		h, err := openHistory(*db)
+		if err != nil {
+			log.Fatalf("opening history: %v", err)
+		}
+		defer h.Close()
+		weekly := *groupBy == "week"
+		starts := trendPeriods(*months, weekly, *rolling-1, time.Now().In(loc))
+		changes, err := h.periodChanges(*author, starts)
+		if err != nil {
+			log.Fatalf("reading history: %v", err)
+		}
+		reportTrend(os.Stdout, changes, starts, *rolling, weekly, flag.Args())
+	case "report":
+		if *tuiOn {
+			if err := runTUI(flag.Args(), opts); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if *watchOn {
+			watch(flag.Args(), opts)
+		}
+
+		os.Exit(report(flag.Args(), opts, wh))
+	}
+	if pullFailed() != nil {
+		os.Exit(exitPull)
+	}
+}
+
+// Exit codes of a report run.
+const (
+	exitNoRepos   = 3 // none of the paths is a repo
+	exitFailed    = 4 // some repos couldn't be opened or pulled, or -verify found mismatches
+	exitNoChanges = 5 // no changes matched the filters
+	exitPull      = 6 // pulling a repo failed with -fail-on-pull-error
+)
+
+// report prints the report of changes in repos at paths, publishes it and
+// returns the exit code.
+func report(paths []string, opts options, wh workHours) int {
+	if !*noPager && *outFile == "" && *output == "table" && !*porcelain {
+		stop := startPager()
+		defer stop()
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if *output == "jsonl" {
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		opts.emit = func(dir directory) {
+			if err := enc.Encode(newJSONRepo(dir, *files)); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	start := time.Now()
+	directories := scan(paths, opts)
+	if pullFailed() != nil {
+		return exitPull
+	}
+	if *delta {
+		addPrevious(directories, paths, opts)
+	}
+	if *prs {
+		addPRActivity(directories, opts)
+	}
+	scanDuration := time.Since(start)
+	switch {
+	case *output == "jsonl":
+		// already streamed
+	case *porcelain:
+		reportPorcelain(out, directories)
+	case *treeOn:
+		reportTree(out, directories)
+	case *keywordsFlag > 0:
+		reportKeywords(out, directories, *keywordsFlag)
+	case *narrateFlag:
+		if err := narrate(out, directories, *dryRun); err != nil {
+			log.Fatalf("-narrate: %v", err)
+		}
+	case *hotspots:
+		reportHotspots(out, directories)
+	case *coupling:
+		reportCoupling(out, directories)
+	case *ownership:
+		reportOwnership(out, directories)
+	case *busiest:
+		reportBusiest(out, directories)
+	case *streaksFlag:
+		reportStreaks(out, directories)
+	case *estimateHours:
+		reportHours(out, workSessions(directories, *maxGap, sessionLead))
+	case *by == "ticket":
+		patterns, err := ticketPatterns(conf.Tickets)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reportTickets(out, directories, patterns)
+		if *ticketStatus {
+			reportAdvancedTickets(out, advancedTickets(directories, patterns))
+		}
+	case *by == "type":
+		reportTypes(out, directories)
+	case *by == "category":
+		reportCategories(out, directories)
+	case *groupBy == "week":
+		reportWeeks(out, directories)
+	case *leaderboard:
+		reportLeaderboard(out, directories)
+	case *matrix != "":
+		if err := writeMatrix(out, directories, *matrix); err != nil {
+			log.Fatal(err)
+		}
+	case *compareAuthors != "":
+		reportComparison(out, directories, splitList(*compareAuthors, ""))
+	default:
+		reportResults(out, directories)
+	}
+	if *workHoursFlag != "" {
+		fmt.Fprintln(out)
+		reportWorkHours(out, directories, wh)
+	}
+	if *chartFile != "" {
+		if err := writeChart(*chartFile, directories); err != nil {
+			log.Printf("-chart: %v", err)
+		}
+	}
+	if *outFile != "" {
+		fmt.Printf("%s, report written to %s\n", totalsOf(directories), *outFile)
+	}
+	publish(directories, opts, scanDuration)
+	if *verify && !verifyResults(os.Stderr, directories, opts) {
+		return exitFailed
+	}
+	return exitCode(directories)
+}
+
+// exitCode returns the exit code of a run that found directories.
+func exitCode(directories []directory) int {
+	switch {
+	case pullFailed() != nil:
+		return exitPull
+	case reposOpened.Load() == 0:
+		return exitNoRepos
+	case reposFailed.Load() > 0:
+		return exitFailed
+	case len(directories) == 0:
+		return exitNoChanges
+	}
+	return 0
+}
+
+// publish sends the results of a run to the history database, metrics sinks
+// and notifiers selected by flags.
+func publish(directories []directory, opts options, scanDuration time.Duration) {
+	if *db != "" {
+		sinceDesc := opts.window.String()
+		if opts.sinceLastRun {
+			sinceDesc = *since
+		}
+		if err := recordHistory(*db, sinceDesc, directories); err != nil {
+			log.Printf("recording history: %v", err)
+		}
+	}
+
+	if *prom != "" {
+		if err := writePrometheusTextfile(*prom, directories); err != nil {
+			log.Printf("writing prometheus textfile: %v", err)
+		}
+	}
+
+	if *statsd != "" {
+		if err := pushStatsd(*statsd, directories, scanDuration); err != nil {
+			log.Printf("pushing to statsd: %v", err)
+		}
+	}
+
+	if *webhook != "" {
+		if err := postWebhook(*webhook, directories, *webhookSummary); err != nil {
+			log.Printf("posting to webhook: %v", err)
+		}
+	}
+
+	if *icsFile != "" {
+		if err := writeICS(*icsFile, workSessions(directories, *maxGap, sessionLead)); err != nil {
+			log.Printf("writing iCalendar: %v", err)
+		}
+	}
+
+	if *timetrack != "" || *jiraWorklog || *harvest {
+		publishTime(directories)
+	}
+
+	if *email != "" {
+		if err := sendEmail(conf.SMTP, strings.Split(*email, ","), directories, *emailHTML); err != nil {
+			log.Printf("sending email: %v", err)
+		}
+	}
+}
+
+// publishTime posts time spent on commits of directories to the time tracking
+// services selected by flags. Time of commits posted by earlier runs isn't
+// posted again.
+func publishTime(directories []directory) {
+	posted, err := loadPosted()
+	if err != nil {
+		log.Printf("loading posted commits: %v", err)
+		return
+	}
+
+	if *timetrack != "" {
+		entries := timeEntries(workSessions(posted.unposted(*timetrack, directories), *maxGap, sessionLead))
+		if err := exportTimeEntries(*timetrack, entries, posted, *dryRun); err != nil {
+			log.Printf("exporting time entries: %v", err)
+		}
+	}
+
+	if *jiraWorklog {
+		worklogs := jiraWorklogs(workSessions(directories, *maxGap, sessionLead), posted)
+		if err := postJiraWorklogs(worklogs, posted, *dryRun); err != nil {
+			log.Printf("posting Jira worklogs: %v", err)
+		}
+	}
+
+	if *harvest {
+		entries, unmapped := harvestEntries(workSessions(posted.unposted("harvest", directories), *maxGap, sessionLead), conf.Harvest.Projects)
+		if len(unmapped) > 0 {
+			log.Printf("no Harvest project for: %s", strings.Join(unmapped, ", "))
+		}
+		if err := createHarvestEntries(entries, posted, *dryRun); err != nil {
+			log.Printf("creating Harvest time entries: %v", err)
+		}
+	}
+
+	if !*dryRun {
+		if err := posted.save(); err != nil {
+			log.Printf("saving posted commits: %v", err)
+		}
+	}
+}
+
+// scan is analyze that remembers the analyzed tips for -since last-run.
+func scan(paths []string, opts options) []directory {
+	runs, err := loadLastRuns()
+	if err != nil {
+		log.Fatalf("loading last run: %v", err)
+	}
+
+	directories := analyze(paths, opts, runs)
+
+	if err := saveLastRuns(runs); err != nil {
+		log.Printf("saving last run: %v", err)
+	}
+	return directories
+}
+
+// Number of repos analyze opened and failed to open or pull.
+var reposOpened, reposFailed atomic.Int32
+
+// pullFailure is the first error of pulling a repo with -fail-on-pull-error.
+var pullFailure struct {
+	sync.Mutex
+	err error
+}
+
+// failPull logs err of pulling a repo and, with -fail-on-pull-error, records
+// it for the run to exit once the workers are done.
+func failPull(err error) {
+	log.Print(err)
+	if !*failOnPullError {
+		return
+	}
+	pullFailure.Lock()
+	defer pullFailure.Unlock()
+	if pullFailure.err == nil {
+		pullFailure.err = err
+	}
+}
+
+// pullFailed returns the error recorded by failPull, if any.
+func pullFailed() error {
+	pullFailure.Lock()
+	defer pullFailure.Unlock()
+	return pullFailure.err
+}
+
+// analyze parses logs of repos at paths for changes selected by opts and
+// returns directories that have some changes. If runs is not nil it's used
+// to find changes since the last run and updated with the analyzed tips.
+func analyze(paths []string, opts options, runs map[string]lastRun) []directory {
+	var runsMu sync.Mutex
+
 	in := make(chan directory)
 	out := make(chan directory)
 
 	var wg sync.WaitGroup
+	gate := newMemGate(*maxMemory)
 
 	// Send directories containing a git repo down the in channel.
 	wg.Add(1)
@@ -68,12 +812,23 @@ func main() {
 		defer wg.Done()
 		defer close(in)
 
-		for _, path := range flag.Args() {
-			repo, err := git.PlainOpen(path)
+		for _, path := range paths {
+			// Absolute paths may be longer than MAX_PATH on Windows.
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				abs = path
+			}
+			repo, err := openRepo(abs)
 			if err != nil {
-				log.Printf("%s: %v", path, err)
+				if !opts.quiet {
+					log.Printf("%s: %v", path, err)
+					reposFailed.Add(1)
+				}
 				continue
 			}
+			if !opts.quiet {
+				reposOpened.Add(1)
+			}
 
 			in <- directory{
 				path: path,
@@ -89,12 +844,71 @@ func main() {
 		go func() {
 			defer wg.Done()
 			for dir := range in {
-				since := time.Hour * 24 * time.Duration(*days)
-				files, err := parseRepoLogs(dir.repo, pull, author, &since)
+				gate.enter()
+				abs, err := filepath.Abs(dir.path)
+				if err != nil {
+					log.Fatalf("%s: %v", dir.path, err)
+				}
+
+				var lastTip string
+				if opts.sinceLastRun && runs != nil {
+					runsMu.Lock()
+					lastTip = runs[abs].Head
+					runsMu.Unlock()
+				}
+
+				o := opts
+				_, err = loadRepoConfig(dir.path)
+				if err == nil {
+					o.ignore, err = ignoreMatcher(dir.path)
+				}
+				if err != nil {
+					// Skipped like repos that can't be opened.
+					if !o.quiet {
+						log.Printf("%s: %v", dir.path, err)
+						reposFailed.Add(1)
+					}
+					gate.leave()
+					continue
+				}
+				if f, ok := dir.repo.(remoteFetcher); ok && o.allRemotes {
+					if o.rev, err = f.fetchRemotes(); err != nil {
+						failPull(fmt.Errorf("fetching remotes of repo %s: %v", dir.path, err))
+						reposFailed.Add(1)
+						dir.stale = true
+					}
+					dir.branch = o.rev
+				}
+				if r, ok := dir.repo.(shallowRepo); ok {
+					since, _ := o.span()
+					start, err := r.shallowStart()
+					switch {
+					case o.quiet:
+						// Warned about by the first pass.
+					case err != nil:
+						log.Printf("%s: %v", dir.path, err)
+					case start.After(since) && o.deepen:
+						if err := r.deepen(since); err != nil {
+							failPull(fmt.Errorf("deepening repo %s: %v", dir.path, err))
+							reposFailed.Add(1)
+							dir.stale = true
+						} else if dir.repo, err = openRepo(abs); err != nil {
+							// Backends may not see the fetched objects otherwise.
+							log.Fatalf("%s: %v", dir.path, err)
+						}
+					case start.After(since):
+						log.Printf("%s: shallow clone whose history starts at %s, changes before are missing; use -deepen to fetch them", dir.path, start.In(loc).Format("2006-01-02"))
+					}
+				}
+				files, commits, err := parseRepoLogs(dir.repo, o, lastTip)
 				if err != nil {
-					switch err.(type) {
+					switch e := err.(type) {
 					case *pullError:
-						log.Printf("pulling repo %s: %v", dir.path, err)
+						failPull(fmt.Errorf("pulling repo %s: %v", dir.path, err))
+						reposFailed.Add(1)
+						dir.stale = true
+					case *detachedError:
+						dir.detached = e.branch
 					default:
 						log.Fatalf("parsing repo %s: %v", dir.path, err)
 					}
@@ -104,6 +918,18 @@ func main() {
 					dir.authors = append(dir.authors, f.authors...)
 				}
 				dir.files = files
+				for i := range commits {
+					commits[i].repo = dir.path
+				}
+				dir.commits = commits
+
+				if head, err := dir.repo.head(); err == nil && runs != nil {
+					runsMu.Lock()
+					runs[abs] = lastRun{Head: head, Time: time.Now()}
+					runsMu.Unlock()
+				}
+
+				gate.leave()
 				out <- dir
 			}
 		}()
@@ -114,45 +940,346 @@ func main() {
 		close(out)
 	}()
 
-	reportResults(out)
+	if !*dedup {
+		return collectResults(out, opts.emit)
+	}
+	directories := dedupe(collectResults(out, nil))
+	if opts.emit != nil {
+		for _, dir := range directories {
+			opts.emit(dir)
+		}
+	}
+	return directories
 }
 
-func reportResults(out chan directory) {
-	var totalChanges int
+// collectResults returns directories from the out channel that have some
+// changes, passing each to emit if it's not nil.
+func collectResults(out chan directory, emit func(directory)) []directory {
 	var directories []directory
 	for dir := range out {
 		if len(dir.files) == 0 {
 			continue
 		}
-		totalChanges += dir.changes
+		if emit != nil {
+			emit(dir)
+		}
 		directories = append(directories, dir)
 	}
+	return directories
+}
+
+// totals sums up directories.
+type totals struct {
+	Repos   int `json:"repos"`
+	Commits int `json:"commits"`
+	Files   int `json:"files"`
+	Changes int `json:"changes"`
+}
+
+func totalsOf(directories []directory) totals {
+	t := totals{Repos: len(directories)}
+	for _, dir := range directories {
+		t.Commits += len(dir.commits)
+		t.Files += len(dir.files)
+		t.Changes += dir.changes
+	}
+	return t
+}
+
+func (t totals) String() string {
+	return fmt.Sprintf("%s, %s, %s, %s", plural(t.Repos, "repo"), plural(t.Commits, "commit"), plural(t.Files, "file"), plural(t.Changes, "line"))
+}
+
+// changesCell formats changes with their share of the total changes or
+// commits as set by -percent-of and -percent-precision.
+func changesCell(changes, commits, totalChanges, totalCommits int) string {
+	var share float64
+	switch *percentOf {
+	case "none":
+		return formatInt(changes)
+	case "commits":
+		share = float64(commits) / float64(totalCommits)
+	default:
+		share = float64(changes) / float64(totalChanges)
+	}
+	width := 2
+	if *percentPrecision > 0 {
+		width += *percentPrecision + 1
+	}
+	return fmt.Sprintf("%*s%% (%s)", width, formatFloat(share*100, *percentPrecision), formatInt(changes))
+}
+
+// plural returns n followed by word, in plural unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func recordHistory(path, since string, directories []directory) error {
+	h, err := openHistory(path)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	return h.record(time.Now(), since, *author, directories)
+}
 
+func reportResults(w io.Writer, directories []directory) {
 	if len(directories) == 0 {
 		return
 	}
 
-	const format = "%v\t%v\t%v\n"
-	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
-	fmt.Fprintf(tw, format, "PATH", "CHANGES", "AUTHORS")
+	total := totalsOf(directories)
+	totalChanges := total.Changes
+
+	// max is the changes of the busiest row.
+	var max int
+	for _, dir := range directories {
+		for _, f := range dir.files {
+			if f.changes > max && *files {
+				max = f.changes
+			}
+		}
+		if dir.changes > max && !*files {
+			max = dir.changes
+		}
+	}
+
+	header := []string{"PATH", "CHANGES"}
+	if *bars {
+		header = append(header, "")
+	}
+	if *delta {
+		header = append(header, "DELTA")
+	}
+	if *metric == "net" {
+		header = append(header, "NET")
+	}
+	withScore := scored(directories)
+	if withScore {
+		header = append(header, "SCORE")
+	}
+	header = append(header, "AUTHORS")
+	authorsCol := len(header) - 1
+	if *signed && !*files {
+		header = append(header, "SIGNED")
+	}
+	if *signoff && !*files {
+		header = append(header, "SIGNOFF")
+	}
+	if *generatedFlag == "flag" && !*files {
+		header = append(header, "GENERATED")
+	}
+	if *testRatio && !*files {
+		header = append(header, "TESTS/CODE")
+	}
+	if *prs && !*files {
+		header = append(header, prHeader()...)
+	}
+	if *why != "" {
+		header = append(header, "WHY")
+	}
+	t := table{header: header, authorsCol: authorsCol}
+	if *why != "" {
+		t.whyCol = len(header) - 1
+	}
+	// changeCells returns the changes cell followed by a bar of n changes
+	// if -bars is set, the DELTA cell if -delta is set, the NET cell if
+	// -metric is net and the SCORE cell if weights are set.
+	changeCells := func(changes string, n int, change string, net int, score float64) []string {
+		cells := []string{changes}
+		if *bars {
+			cells = append(cells, scaledBar(n, max))
+		}
+		if *delta {
+			cells = append(cells, change)
+		}
+		if *metric == "net" {
+			cells = append(cells, fmt.Sprintf("%+d", net))
+		}
+		if withScore {
+			cells = append(cells, fmt.Sprintf("%.0f", score))
+		}
+		return cells
+	}
+
+	// style highlights the busiest rows and dims the negligible ones.
+	style := func(changes int) string {
+		switch {
+		case changes*2 >= max:
+			return bold
+		case changes*100 < totalChanges:
+			return dim
+		}
+		return ""
+	}
 
-	sort.Sort(sort.Reverse(byDirChanges(directories)))
+	sortDirectories(directories)
 	for _, dir := range directories {
 		if *files {
-			sort.Sort(sort.Reverse(byFileChanges(dir.files)))
 			for _, f := range dir.files {
-				changes := fmt.Sprintf("%2.0f%% (%d)", float64(f.changes)/float64(totalChanges)*100, f.changes)
-				authors := strings.Join(uniq(f.authors), ", ")
-				fmt.Fprintf(tw, format, filepath.Join(dir.path, f.path), changes, authors)
+				nCommits := dir.countCommits(func(c commitInfo) bool { return c.touches(f.path) })
+				changes := changesCell(f.changes, nCommits, totalChanges, total.Commits)
+				authors := formatAuthors(dir.authorChanges(f.path), *maxAuthors)
+				row := append([]string{filepath.Join(displayPath(dir.path), f.path)}, changeCells(changes, f.changes, dir.delta(f.path), dir.netChanges(f.path), dir.score(f.path))...)
+				row = append(row, authors)
+				if *why != "" {
+					row = append(row, dir.why(*why, f.path))
+				}
+				t.add(style(f.changes), row...)
 			}
 		} else {
-			changes := fmt.Sprintf("%2.0f%% (%d)", float64(dir.changes)/float64(totalChanges)*100, dir.changes)
-			authors := strings.Join(uniq(dir.authors), ", ")
-			fmt.Fprintf(tw, format, dir.path, changes, authors)
+			changes := changesCell(dir.changes, len(dir.commits), totalChanges, total.Commits)
+			authors := formatAuthors(dir.authorChanges(""), *maxAuthors)
+			path := displayPath(dir.path)
+			if dir.stale {
+				path += " (stale)"
+			}
+			if dir.detached != "" {
+				path += " (detached, " + dir.detached + ")"
+			} else if dir.branch != "" {
+				path += " (" + dir.branch + ")"
+			}
+			row := append(append([]string{path}, changeCells(changes, dir.changes, dir.delta(""), dir.netChanges(""), dir.score(""))...), authors)
+			if *signed {
+				n := dir.countCommits(func(c commitInfo) bool { return c.signed })
+				row = append(row, fmt.Sprintf("%d/%d", n, len(dir.commits)))
+			}
+			if *signoff {
+				n := dir.countCommits(func(c commitInfo) bool { return c.signoff })
+				row = append(row, fmt.Sprintf("%d/%d", n, len(dir.commits)))
+			}
+			if *generatedFlag == "flag" {
+				row = append(row, percent(dir.generatedChanges(), dir.changes))
+			}
+			if *testRatio {
+				row = append(row, testRatioCell(dir.testChurn()))
+			}
+			if *prs {
+				row = append(row, dir.prs.cells()...)
+			}
+			if *why != "" {
+				row = append(row, dir.why(*why, ""))
+			}
+			t.add(style(dir.changes), row...)
+		}
+	}
+
+	all := make(map[string]int)
+	var signedCommits, signoffCommits, net, tests, code, generated int
+	var score float64
+	var pulls prActivity
+	totalDelta := ""
+	var current, previous int
+	for _, dir := range directories {
+		if dir.previous != nil {
+			current += dir.changes
+			previous += dir.previous.changes
+			totalDelta = formatDelta(current, previous)
+		}
+		net += dir.netChanges("")
+		score += dir.score("")
+		for a, n := range dir.authorChanges("") {
+			all[a] += n
+		}
+		signedCommits += dir.countCommits(func(c commitInfo) bool { return c.signed })
+		signoffCommits += dir.countCommits(func(c commitInfo) bool { return c.signoff })
+		generated += dir.generatedChanges()
+		dirTests, dirCode := dir.testChurn()
+		tests += dirTests
+		code += dirCode
+		if dir.prs != nil {
+			pulls.add(*dir.prs)
+		}
+	}
+	row := append([]string{"TOTAL"}, changeCells(changesCell(totalChanges, total.Commits, totalChanges, total.Commits), 0, totalDelta, net, score)...)
+	row = append(row, formatAuthors(all, *maxAuthors))
+	if *signed && !*files {
+		row = append(row, fmt.Sprintf("%d/%d", signedCommits, total.Commits))
+	}
+	if *signoff && !*files {
+		row = append(row, fmt.Sprintf("%d/%d", signoffCommits, total.Commits))
+	}
+	if *generatedFlag == "flag" && !*files {
+		row = append(row, percent(generated, totalChanges))
+	}
+	if *testRatio && !*files {
+		row = append(row, testRatioCell(tests, code))
+	}
+	if *prs && !*files {
+		row = append(row, pulls.cells()...)
+	}
+	if *why != "" {
+		row = append(row, "")
+	}
+	t.add(bold, row...)
+	t.write(w)
+
+	fmt.Fprintln(w, total)
+}
+
+// displayPath returns path of a repo redacted, named in config or in the
+// -path-style.
+func displayPath(path string) string {
+	if *redactFlag != "" {
+		return redact(path)
+	}
+	if name := configuredName(path); name != "" {
+		return name
+	}
+	if *pathStyle == "" {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	switch *pathStyle {
+	case "abs":
+		return abs
+	case "rel":
+		wd, err := os.Getwd()
+		if err != nil {
+			return path
+		}
+		if rel, err := filepath.Rel(wd, abs); err == nil {
+			return rel
+		}
+		return path
+	case "name":
+		return repoName(path)
+	}
+	return path
+}
+
+// sortDirectories orders directories and their files by the -sort key,
+// reversed if -reverse is set. Directories and files with equal changes are
+// ordered by path.
+func sortDirectories(directories []directory) {
+	sort.Sort(byDirPath(directories))
+	if *sortBy == "changes" {
+		sort.Stable(sort.Reverse(byDirChanges(directories)))
+	}
+	if *reverse {
+		reverseSlice(directories)
+	}
+	for _, dir := range directories {
+		sort.Sort(byFilePath(dir.files))
+		if *sortBy == "changes" {
+			sort.Stable(sort.Reverse(byFileChanges(dir.files)))
+		}
+		if *reverse {
+			reverseSlice(dir.files)
 		}
 	}
+}
 
-	tw.Flush()
+func reverseSlice[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
 }
 
 type byFileChanges []file
@@ -167,6 +1294,18 @@ func (x byDirChanges) Len() int           { return len(x) }
 func (x byDirChanges) Less(i, j int) bool { return x[i].changes < x[j].changes }
 func (x byDirChanges) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
 
+type byFilePath []file
+
+func (x byFilePath) Len() int           { return len(x) }
+func (x byFilePath) Less(i, j int) bool { return x[i].path < x[j].path }
+func (x byFilePath) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+type byDirPath []directory
+
+func (x byDirPath) Len() int           { return len(x) }
+func (x byDirPath) Less(i, j int) bool { return x[i].path < x[j].path }
+func (x byDirPath) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
 type pullError struct {
 	Err error
 }
@@ -175,85 +1314,53 @@ func (e *pullError) Error() string {
 	return fmt.Sprint(e.Err)
 }
 
-func parseRepoLogs(repo *git.Repository, pull *bool, author *string, since *time.Duration) (files []file, err error) {
-	if *pull {
-		if err := pullRepo(repo); err != nil {
-			return nil, &pullError{Err: err}
-		}
-	}
-
-	t := time.Now().Add(-*since)
-	cIter, err := repo.Log(&git.LogOptions{Since: &t})
-	if err != nil {
-		return nil, err
-	}
+// detachedError tells that a repo with detached HEAD, like after checking out
+// a tag, was fetched instead of pulled and its default remote branch should
+// be analyzed.
+type detachedError struct {
+	branch string // like origin/main
+}
 
-	changesPerFile := make(map[string]int)
-	authorsPerFile := make(map[string][]string)
-	msgsPerFile := make(map[string][]string)
-	err = cIter.ForEach(func(commit *object.Commit) error {
-		if *author != "" && commit.Author.Name != *author {
-			return nil
-		}
+func (e *detachedError) Error() string {
+	return "HEAD detached, analyzing " + e.branch
+}
 
-		stats, err := commit.Stats()
+// pullRepos pulls repos at paths and returns the exit code.
+func pullRepos(paths []string) int {
+	var opened, failed int
+	for _, path := range paths {
+		repo, err := openRepo(path)
 		if err != nil {
-			return err
+			log.Printf("%s: %v", path, err)
+			failed++
+			continue
 		}
-
-		for _, stat := range stats {
-			file, nChanges := parseStat(stat)
-			if file != "" { // only content changes
-				changesPerFile[file] += nChanges
-			}
-
-			authorsPerFile[file] = append(authorsPerFile[file], commit.Author.Name)
-
-			lines := strings.Split(commit.Message, "\n")
-			msgsPerFile[file] = append(msgsPerFile[file], lines[0])
+		opened++
+		if err := repo.pull(); err != nil {
+			log.Printf("pulling repo %s: %v", path, err)
+			failed++
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
-
-	for f, c := range changesPerFile {
-		files = append(files, file{
-			path:    f,
-			changes: c,
-			authors: uniq(authorsPerFile[f]),
-		})
+	switch {
+	case opened == 0:
+		return exitNoRepos
+	case failed > 0:
+		return exitFailed
 	}
-
-	return
+	return 0
 }
 
-func pullRepo(repo *git.Repository) error {
-	w, err := repo.Worktree()
-	if err != nil {
-		return err
-	}
-
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-	privateKeyFile := filepath.Join(home, ".ssh", "id_rsa")
-
-	publicKeys, err := ssh.NewPublicKeysFromFile("git", privateKeyFile, "")
-	if err != nil {
-		return err
-	}
-
-	err = w.Pull(&git.PullOptions{
-		Auth: publicKeys,
-	})
-	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-		return err
+// splitList splits a comma-separated list trimming cutset from the items,
+// like the dot from .go, and dropping empty ones.
+func splitList(s, cutset string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.Trim(strings.TrimSpace(item), cutset)
+		if item != "" {
+			items = append(items, item)
+		}
 	}
-	return nil
+	return items
 }
 
 func uniq(ss []string) []string {
@@ -267,19 +1374,3 @@ func uniq(ss []string) []string {
 	}
 	return uniq
 }
-
-func parseStat(stat object.FileStat) (file string, nChanges int) {
-	count := make(map[string]int)
-	if _, ok := count[stat.Name]; !ok {
-		count[stat.Name]++
-	}
-	file = stat.Name
-	nChanges += stat.Addition
-	nChanges += stat.Deletion
-	for _, v := range count {
-		if v > 1 {
-			log.Fatalf("didn't expect this: %v", count)
-		}
-	}
-	return
-}
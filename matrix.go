@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+var htmlMatrix = template.Must(template.New("matrix").Parse(`<html>
+<body>
+<table>
+<tr><th align="left">PATH</th>{{range .Authors}}<th align="right">{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><td>{{.Path}}</td>{{range .Changes}}<td align="right">{{if .}}{{.}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// writeMatrix writes changes of each author, as columns, in each repo, as
+// rows, to w in format csv or html. Authors with the most changes come first
+// and the last row and column are totals.
+func writeMatrix(w io.Writer, directories []directory, format string) error {
+	all := make(map[string]int)
+	for _, dir := range directories {
+		for a, n := range dir.authorChanges("") {
+			all[a] += n
+		}
+	}
+	var authors []string
+	for _, s := range shares(all) {
+		authors = append(authors, s.author)
+	}
+
+	type row struct {
+		Path    string
+		Changes []int // per author followed by total
+	}
+	var rows []row
+	sortDirectories(directories)
+	for _, dir := range directories {
+		perAuthor := dir.authorChanges("")
+		r := row{Path: displayPath(dir.path)}
+		for _, a := range authors {
+			r.Changes = append(r.Changes, perAuthor[a])
+		}
+		r.Changes = append(r.Changes, dir.changes)
+		rows = append(rows, r)
+	}
+	total := row{Path: "TOTAL"}
+	for _, a := range authors {
+		total.Changes = append(total.Changes, all[a])
+	}
+	total.Changes = append(total.Changes, totalsOf(directories).Changes)
+	rows = append(rows, total)
+	authors = append(authors, "TOTAL")
+
+	if format == "html" {
+		return htmlMatrix.Execute(w, struct {
+			Authors []string
+			Rows    []row
+		}{authors, rows})
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"path"}, authors...))
+	for _, r := range rows {
+		record := []string{r.Path}
+		for _, n := range r.Changes {
+			record = append(record, fmt.Sprint(n))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
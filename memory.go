@@ -0,0 +1,60 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// memGate lowers the number of repos analyzed at once to one while the heap
+// is bigger than limit bytes. Zero limit means no limit.
+type memGate struct {
+	limit uint64
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+}
+
+func newMemGate(limitMiB int) *memGate {
+	g := &memGate{limit: uint64(limitMiB) << 20}
+	g.cond = sync.NewCond(&g.mu)
+	if g.limit > 0 {
+		// Make the garbage collector work harder near the limit too.
+		debug.SetMemoryLimit(int64(g.limit))
+	}
+	return g
+}
+
+// enter waits until analyzing another repo fits in the limit.
+func (g *memGate) enter() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.active > 0 && g.overLimit() {
+		g.cond.Wait()
+	}
+	g.active++
+}
+
+// leave marks analyzing a repo as done.
+func (g *memGate) leave() {
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+func (g *memGate) overLimit() bool {
+	if g.limit == 0 {
+		return false
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapInuse <= g.limit {
+		return false
+	}
+	// Some of it may be garbage already.
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse > g.limit
+}
@@ -0,0 +1,56 @@
+package main
+
+import "strings"
+
+// minMovedLen is how many characters, ignoring surrounding whitespace, a
+// line needs to be detected as moved. Shorter lines like closing braces are
+// too common to tell whether they were moved.
+const minMovedLen = 10
+
+// patchFile holds lines added and deleted in a file by a commit.
+type patchFile struct {
+	path    string
+	added   []string
+	deleted []string
+}
+
+// fileChanges returns changes per file in patch leaving out files with no
+// changes. If discountMoved is true, lines deleted in one place and added in
+// another, in the same or another file of the commit, are not counted.
+// Whitespace around moved lines may change, so reindented code is moved too.
+func fileChanges(patch []patchFile, discountMoved bool) []fileChange {
+	added := make([]int, len(patch))
+	deleted := make([]int, len(patch))
+	for i, f := range patch {
+		added[i], deleted[i] = len(f.added), len(f.deleted)
+	}
+	if discountMoved {
+		deletedIn := make(map[string][]int) // line -> files it's deleted in
+		for i, f := range patch {
+			for _, l := range f.deleted {
+				if l = strings.TrimSpace(l); len(l) >= minMovedLen {
+					deletedIn[l] = append(deletedIn[l], i)
+				}
+			}
+		}
+		for i, f := range patch {
+			for _, l := range f.added {
+				from := deletedIn[strings.TrimSpace(l)]
+				if len(from) == 0 {
+					continue
+				}
+				deletedIn[strings.TrimSpace(l)] = from[:len(from)-1]
+				added[i]--
+				deleted[from[len(from)-1]]--
+			}
+		}
+	}
+
+	var files []fileChange
+	for i, f := range patch {
+		if n := added[i] + deleted[i]; n > 0 {
+			files = append(files, fileChange{path: f.path, changes: n, net: added[i] - deleted[i]})
+		}
+	}
+	return files
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+// remotes are URLs of the default remotes of repos by absolute path.
+var remotes sync.Map
+
+// remoteURL returns the URL of the origin remote of the git repo, or of the
+// default path of the Mercurial repo, at absolute path abs, or "".
+func remoteURL(abs string) string {
+	if u, ok := remotes.Load(abs); ok {
+		return u.(string)
+	}
+	var url string
+	if f, err := os.Open(filepath.Join(abs, ".git", "config")); err == nil {
+		if c, err := gitconfig.ReadConfig(f); err == nil && c.Remotes["origin"] != nil && len(c.Remotes["origin"].URLs) > 0 {
+			url = c.Remotes["origin"].URLs[0]
+		}
+		f.Close()
+	} else if f, err := os.Open(filepath.Join(abs, ".hg", "hgrc")); err == nil {
+		var inPaths bool
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if strings.HasPrefix(line, "[") {
+				inPaths = line == "[paths]"
+				continue
+			}
+			if k, v, ok := strings.Cut(line, "="); ok && inPaths && strings.TrimSpace(k) == "default" {
+				url = strings.TrimSpace(v)
+			}
+		}
+		f.Close()
+	}
+	remotes.Store(abs, url)
+	return url
+}
+
+// configuredName returns the name of repo at path from the names in config,
+// looked up by its absolute path, remote URL with or without .git and
+// directory name, or "".
+func configuredName(path string) string {
+	if len(conf.Names) == 0 {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	keys := []string{abs}
+	if url := remoteURL(abs); url != "" {
+		url = strings.TrimSuffix(url, ".git")
+		keys = append(keys, url, url+".git")
+	}
+	for _, k := range append(keys, filepath.Base(abs)) {
+		if name, ok := conf.Names[k]; ok {
+			return name
+		}
+	}
+	return ""
+}
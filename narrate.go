@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultNarrateURL is the OpenAI compatible chat completions endpoint of
+// Ollama, so commits stay on the machine unless config says otherwise.
+const defaultNarrateURL = "http://localhost:11434/v1/chat/completions"
+
+// narrateFiles is how many of the most changed files of a repo are sent.
+const narrateFiles = 20
+
+// narrateClient waits longer than httpClient as local models may take a
+// while to answer.
+var narrateClient = &http.Client{Timeout: 5 * time.Minute}
+
+const narratePrompt = `You summarize a developer's work for a weekly report.
+Write a concise summary in the first person, past tense, of at most five
+sentences. Group related changes, name themes rather than listing commits, and
+don't invent anything beyond the commits and files below.`
+
+// narrateInput returns commit subjects and most changed files of directories
+// to be sent to the model. Subjects of fixup commits and repeated ones are
+// left out, as are files matching exclude patterns of config, and text
+// matching its redact patterns is replaced.
+func narrateInput(directories []directory) (string, error) {
+	var redactions []*regexp.Regexp
+	for _, s := range conf.Narrate.Redact {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return "", fmt.Errorf("narrate.redact: %v", err)
+		}
+		redactions = append(redactions, re)
+	}
+	scrub := func(s string) string {
+		for _, re := range redactions {
+			s = re.ReplaceAllString(s, "[redacted]")
+		}
+		return s
+	}
+
+	var b strings.Builder
+	sort.Sort(byDirPath(directories))
+	for _, dir := range directories {
+		// Names of repos, not paths that may tell more than needed.
+		name := displayPath(dir.path)
+		if name == dir.path {
+			if abs, err := filepath.Abs(dir.path); err == nil {
+				name = filepath.Base(abs)
+			}
+		}
+		fmt.Fprintf(&b, "Repository %s\n", scrub(name))
+		commits := append([]commitInfo(nil), dir.commits...)
+		sort.Slice(commits, func(i, j int) bool { return commits[i].when.Before(commits[j].when) })
+		seen := make(map[string]bool)
+		for _, c := range commits {
+			if strings.HasPrefix(c.subject, "fixup!") || strings.HasPrefix(c.subject, "squash!") || seen[c.subject] {
+				continue
+			}
+			seen[c.subject] = true
+			fmt.Fprintf(&b, "- %s\n", scrub(c.subject))
+		}
+
+		files := append([]file(nil), dir.files...)
+		sort.Slice(files, func(i, j int) bool { return files[i].changes > files[j].changes })
+		var paths []string
+		for _, f := range files {
+			if len(paths) == narrateFiles {
+				break
+			}
+			if !narrateExcluded(f.path) {
+				paths = append(paths, scrub(f.path))
+			}
+		}
+		if len(paths) > 0 {
+			fmt.Fprintf(&b, "Most changed files: %s\n", strings.Join(paths, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// narrateExcluded tells whether path matches an exclude pattern of config,
+// as a whole or by its base name.
+func narrateExcluded(path string) bool {
+	for _, pattern := range conf.Narrate.Exclude {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// narrate writes a first-person summary of the work in directories written
+// by a language model. If dryRun is true it only prints what would be sent.
+func narrate(w io.Writer, directories []directory, dryRun bool) error {
+	if len(directories) == 0 {
+		return nil
+	}
+	input, err := narrateInput(directories)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Fprintf(w, "%s\n\n%s", narratePrompt, input)
+		return nil
+	}
+
+	c := conf.Narrate
+	url := c.URL
+	if url == "" {
+		url = defaultNarrateURL
+	}
+	model := c.Model
+	if model == "" {
+		model = "llama3.2"
+	}
+	body := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "system", "content": narratePrompt},
+			{"role": "user", "content": input},
+		},
+		"temperature": 0.2,
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := narrateClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	if len(completion.Choices) == 0 {
+		return fmt.Errorf("%s: no answer", url)
+	}
+	fmt.Fprintln(w, strings.TrimSpace(completion.Choices[0].Message.Content))
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// share is an author's part of the changes.
+type share struct {
+	author  string
+	changes int
+}
+
+// shares returns changes per author, largest first.
+func shares(perAuthor map[string]int) []share {
+	var ss []share
+	for a, n := range perAuthor {
+		ss = append(ss, share{a, n})
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].changes != ss[j].changes {
+			return ss[i].changes > ss[j].changes
+		}
+		return ss[i].author < ss[j].author
+	})
+	return ss
+}
+
+// busFactor returns the smallest number of authors who together made more
+// than half of the changes.
+func busFactor(ss []share) int {
+	var total int
+	for _, s := range ss {
+		total += s.changes
+	}
+	var sum int
+	for i, s := range ss {
+		sum += s.changes
+		if sum*2 > total {
+			return i + 1
+		}
+	}
+	return len(ss)
+}
+
+// topDir returns the first component of a slash-separated path, or "." for
+// files in the root directory.
+func topDir(path string) string {
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i+1]
+	}
+	return "."
+}
+
+// reportOwnership prints each author's share of changes and the bus factor
+// for every repo as a whole (DIR *) and for its top-level directories.
+func reportOwnership(w io.Writer, directories []directory) {
+	sortDirectories(directories)
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "PATH", "DIR", "BUS FACTOR", "AUTHORS")
+	for _, dir := range directories {
+		repo := make(map[string]int)
+		perDir := make(map[string]map[string]int)
+		for _, c := range dir.commits {
+			for _, f := range c.files {
+				repo[c.author] += f.changes
+				d := topDir(f.path)
+				if perDir[d] == nil {
+					perDir[d] = make(map[string]int)
+				}
+				perDir[d][c.author] += f.changes
+			}
+		}
+
+		var dirs []string
+		for d := range perDir {
+			dirs = append(dirs, d)
+		}
+		sort.Strings(dirs)
+
+		row := func(d string, perAuthor map[string]int) {
+			ss := shares(perAuthor)
+			var total int
+			for _, s := range ss {
+				total += s.changes
+			}
+			var authors []string
+			for _, s := range ss {
+				authors = append(authors, fmt.Sprintf("%s %s", s.author, percent(s.changes, total)))
+			}
+			fmt.Fprintf(tw, format, displayPath(dir.path), d, busFactor(ss), strings.Join(authors, ", "))
+		}
+		row("*", repo)
+		for _, d := range dirs {
+			row(d, perDir[d])
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+
+	"golang.org/x/term"
+)
+
+// startPager collects what's written to stdout until the returned function
+// is called. Then, if the output doesn't fit the terminal, it's shown by
+// $PAGER (default less), otherwise it's written to the terminal directly.
+// Nothing is done unless stdout is a terminal.
+func startPager() (stop func()) {
+	tty := os.Stdout
+	if !isTerminal(tty) {
+		return func() {}
+	}
+	_, height, err := term.GetSize(int(tty.Fd()))
+	if err != nil {
+		return func() {}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+	os.Stdout = w
+
+	return func() {
+		w.Close()
+		<-done
+		os.Stdout = tty
+
+		if bytes.Count(buf.Bytes(), []byte("\n")) < height {
+			tty.Write(buf.Bytes())
+			return
+		}
+		pager := os.Getenv("PAGER")
+		if pager == "" {
+			pager = "less"
+		}
+		cmd := exec.Command("sh", "-c", pager)
+		cmd.Stdin = &buf
+		cmd.Stdout = tty
+		cmd.Stderr = os.Stderr
+		if os.Getenv("LESS") == "" {
+			cmd.Env = append(os.Environ(), "LESS=FRX") // like git
+		}
+		if err := cmd.Run(); err != nil {
+			log.Printf("pager: %v", err)
+			tty.Write(buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// patchID returns a hash of changes in patch that, like git patch-id, is the
+// same for commits making the same changes regardless of where they apply,
+// such as a commit cherry-picked to another branch. Whitespace is ignored.
+// Empty patches have no ID.
+func patchID(patch []patchFile) string {
+	files := append([]patchFile(nil), patch...)
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	h := sha1.New()
+	var lines int
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00", f.path)
+		for _, l := range f.added {
+			io.WriteString(h, "+"+strings.Join(strings.Fields(l), "")+"\n")
+		}
+		for _, l := range f.deleted {
+			io.WriteString(h, "-"+strings.Join(strings.Fields(l), "")+"\n")
+		}
+		lines += len(f.added) + len(f.deleted)
+	}
+	if lines == 0 {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dropPicked drops commits making the same changes as an older commit, like
+// cherry-picks of a commit to other branches or a squash merge of a branch
+// with one commit.
+func dropPicked(commits []commitInfo) []commitInfo {
+	first := make(map[string]commitInfo) // patch ID -> oldest commit
+	for _, c := range commits {
+		if c.patchID == "" {
+			continue
+		}
+		if f, ok := first[c.patchID]; !ok || c.when.Before(f.when) {
+			first[c.patchID] = c
+		}
+	}
+	var kept []commitInfo
+	for _, c := range commits {
+		if c.patchID == "" || first[c.patchID].hash == c.hash {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
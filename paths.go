@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// foldCase tells whether file names differing only in case name the same
+// file, like on Windows, so filters match them ignoring case.
+var foldCase = runtime.GOOS == "windows"
+
+// slashPath returns path of a file in a repo, or a pattern matching such
+// paths, given with slashes or backslashes with slashes, as repos store them.
+func slashPath(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// foldPath returns path of a file in a repo as it's compared with filters.
+func foldPath(path string) string {
+	if foldCase {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// parseIgnorePattern parses line of an ignore file or exclude list.
+func parseIgnorePattern(line string) gitignore.Pattern {
+	return gitignore.ParsePattern(foldPath(slashPath(line)), nil)
+}
+
+// pathElems returns directories leading to path below dir, and path's last
+// element. If dir is empty path is split from its volume, like C: or
+// \\host\share on Windows.
+func pathElems(dir, path string) []string {
+	sep := string(filepath.Separator)
+	if dir == "" {
+		vol := filepath.VolumeName(path)
+		rest := strings.Trim(path[len(vol):], sep)
+		if vol == "" {
+			vol = sep
+		}
+		return append([]string{vol}, strings.Split(rest, sep)...)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return []string{path}
+	}
+	return strings.Split(rel, sep)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// reportPorcelain prints the report in a format for scripts that stays the
+// same across versions. Each line is a record of tab-separated fields, the
+// first naming the record type:
+//
+//	repo  PATH  CHANGES  COMMITS  AUTHORS
+//	file  REPO  PATH  CHANGES  AUTHORS
+//
+// Paths of repos are absolute, paths of files relative to their repo and
+// authors are separated by commas. File records follow the record of their
+// repo and are printed only with -files.
+func reportPorcelain(w io.Writer, directories []directory) {
+	sortDirectories(directories)
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			abs = dir.path
+		}
+		abs = redact(abs)
+		fmt.Fprintf(w, "repo\t%s\t%d\t%d\t%s\n", abs, dir.changes, len(dir.commits), strings.Join(dir.authorNames(""), ","))
+		if !*files {
+			continue
+		}
+		for _, f := range dir.files {
+			fmt.Fprintf(w, "file\t%s\t%s\t%d\t%s\n", abs, f.path, f.changes, strings.Join(dir.authorNames(f.path), ","))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAuthorsOrderStable(t *testing.T) {
+	defer func(old bool) { *files = old }(*files)
+	*files = true
+
+	when := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	var commits []commitInfo
+	for i, author := range []string{"Cy", "Al", "Bo", "Di", "Ed", "Al", "Fay"} {
+		commits = append(commits, commitInfo{
+			repo:    "repo",
+			hash:    fmt.Sprint(i),
+			author:  author,
+			when:    when.Add(time.Duration(i) * time.Minute),
+			changes: 1,
+			files:   []fileChange{{path: fmt.Sprintf("f%d.go", i%3), changes: 1}},
+		})
+	}
+	output := func() string {
+		dir := directory{path: "/repo", commits: commits}
+		// Files and authors come from maps, in a different order each time.
+		dir.recount()
+		var b bytes.Buffer
+		reportPorcelain(&b, []directory{dir})
+		if err := json.NewEncoder(&b).Encode(newJSONRepo(dir, true)); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+
+	want := output()
+	for i := 0; i < 20; i++ {
+		if got := output(); got != want {
+			t.Fatalf("output changed between runs:\n%s\nthen:\n%s", want, got)
+		}
+	}
+	if !bytes.Contains([]byte(want), []byte("\tAl,Bo,Cy,Di,Ed,Fay\n")) {
+		t.Errorf("authors not ordered by changes and name:\n%s", want)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// postedCommits are hashes of commits whose time was posted, per time
+// tracking service, so that later runs and daemon ticks post only the time
+// of new commits instead of the whole window again. Jira commits are keyed
+// by worklogKey.
+type postedCommits map[string]map[string]bool
+
+// postedFile returns the path of the file recording posted commits.
+func postedFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workedon", "posted.json"), nil
+}
+
+// loadPosted returns the posted commits. A missing file is not an error.
+func loadPosted() (postedCommits, error) {
+	posted := make(postedCommits)
+
+	name, err := postedFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return posted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &posted); err != nil {
+		return nil, err
+	}
+	return posted, nil
+}
+
+func (p postedCommits) save() error {
+	name, err := postedFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// unposted returns directories with only the commits not yet posted to
+// service.
+func (p postedCommits) unposted(service string, directories []directory) []directory {
+	var dirs []directory
+	for _, dir := range directories {
+		var commits []commitInfo
+		for _, c := range dir.commits {
+			if !p[service][c.hash] {
+				commits = append(commits, c)
+			}
+		}
+		dir.commits = commits
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// mark records commits as posted to service.
+func (p postedCommits) mark(service string, commits []string) {
+	for _, h := range commits {
+		if p[service] == nil {
+			p[service] = make(map[string]bool)
+		}
+		p[service][h] = true
+	}
+}
+
+// hashes returns hashes of commits.
+func hashes(commits []commitInfo) []string {
+	var hs []string
+	for _, c := range commits {
+		hs = append(hs, c.hash)
+	}
+	return hs
+}
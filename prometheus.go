@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// writePrometheusTextfile writes changes and commits per repo and per author
+// in the node_exporter textfile collector format. The file is replaced
+// atomically so the collector never reads a partial file.
+func writePrometheusTextfile(path string, directories []directory) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".workedon-*.prom")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+
+	type counts struct{ changes, commits int }
+	perRepo := make(map[string]*counts)
+	perAuthor := make(map[string]*counts)
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			abs = dir.path
+		}
+		abs = redact(abs)
+		perRepo[abs] = &counts{changes: dir.changes, commits: len(dir.commits)}
+		for _, c := range dir.commits {
+			a, ok := perAuthor[c.author]
+			if !ok {
+				a = &counts{}
+				perAuthor[c.author] = a
+			}
+			a.changes += c.changes
+			a.commits++
+		}
+	}
+
+	metric := func(name, help, label string, values map[string]*counts, value func(*counts) int) {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		var keys []string
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, label, escapeLabel(k), value(values[k]))
+		}
+	}
+	changes := func(c *counts) int { return c.changes }
+	commits := func(c *counts) int { return c.commits }
+	metric("workedon_repo_changes", "Lines added and deleted per repo.", "repo", perRepo, changes)
+	metric("workedon_repo_commits", "Commits per repo.", "repo", perRepo, commits)
+	metric("workedon_author_changes", "Lines added and deleted per author.", "author", perAuthor, changes)
+	metric("workedon_author_commits", "Commits per author.", "author", perAuthor, commits)
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// prActivity is pull or merge requests, or Gerrit changes, the author
+// opened, reviewed and got merged in the window, and review comments they
+// wrote.
+type prActivity struct {
+	Opened   int `json:"opened"`
+	Reviewed int `json:"reviewed"`
+	Merged   int `json:"merged"`
+	// Comments are code review comments written on changes of others.
+	Comments int `json:"comments"`
+	// Patchsets are patch sets uploaded to Gerrit.
+	Patchsets int `json:"patchsets,omitempty"`
+}
+
+func (a *prActivity) add(b prActivity) {
+	a.Opened += b.Opened
+	a.Reviewed += b.Reviewed
+	a.Merged += b.Merged
+	a.Comments += b.Comments
+	a.Patchsets += b.Patchsets
+}
+
+// withPatchsets tells whether PATCHSETS are shown with -prs, as some repos
+// may be on Gerrit.
+func withPatchsets() bool {
+	return len(conf.Gerrit) > 0
+}
+
+// addPRActivity sets prs of directories hosted on GitHub, GitLab or Gerrit
+// to the pull or merge requests, or changes, of the author of opts in the
+// window.
+func addPRActivity(directories []directory, opts options) {
+	since, until := opts.span()
+	var wg sync.WaitGroup
+	for i := range directories {
+		abs, err := filepath.Abs(directories[i].path)
+		if err != nil {
+			abs = directories[i].path
+		}
+		remote := remoteURL(abs)
+		var activity func() (prActivity, error)
+		if api, repo, ok := githubRepo(remote); ok {
+			login := githubLogin(opts.author)
+			activity = func() (prActivity, error) { return githubActivity(api, repo, login, since, until) }
+		} else if host, project, ok := gitlabProject(remote); ok {
+			username := gitlabUsername(host, opts.author)
+			activity = func() (prActivity, error) { return gitlabActivity(host, project, username, since, until) }
+		} else if host, project, ok := gerritProject(remote); ok {
+			account := gerritAccount(host, opts.author)
+			activity = func() (prActivity, error) { return gerritActivity(host, project, account, since, until) }
+		} else {
+			continue
+		}
+		wg.Add(1)
+		go func(dir *directory) {
+			defer wg.Done()
+			a, err := activity()
+			if err != nil {
+				log.Printf("%s: pull requests: %v", dir.path, err)
+				return
+			}
+			dir.prs = &a
+		}(&directories[i])
+	}
+	wg.Wait()
+}
+
+// prHeader returns the headers of the -prs columns.
+func prHeader() []string {
+	header := []string{"OPENED", "REVIEWED", "MERGED", "REVIEWS"}
+	if withPatchsets() {
+		header = append(header, "PATCHSETS")
+	}
+	return header
+}
+
+// cells returns the -prs cells of a, empty if a is nil as its pull requests
+// aren't known.
+func (a *prActivity) cells() []string {
+	if a == nil {
+		return make([]string, len(prHeader()))
+	}
+	cells := []string{formatInt(a.Opened), formatInt(a.Reviewed), formatInt(a.Merged), formatInt(a.Comments)}
+	if withPatchsets() {
+		cells = append(cells, formatInt(a.Patchsets))
+	}
+	return cells
+}
+
+// remoteHostPath returns the host and the path of the repo on it of remote
+// URL given like https://host/path, git@host:path or ssh://host/path.
+func remoteHostPath(remote string) (host, path string, ok bool) {
+	ep, err := transport.NewEndpoint(remote)
+	if err != nil || ep.Host == "" {
+		return "", "", false
+	}
+	path = strings.TrimSuffix(strings.Trim(ep.Path, "/"), ".git")
+	return ep.Host, path, path != ""
+}
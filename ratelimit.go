@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRetries is how many times a rate-limited API request is retried.
+const maxRetries = 5
+
+// apiLimit keeps requests to an API host within its rate limits.
+type apiLimit struct {
+	Rate        float64 `yaml:"rate"`        // requests per second
+	Burst       int     `yaml:"burst"`       // requests sent at once before Rate applies
+	Concurrency int     `yaml:"concurrency"` // requests in flight
+}
+
+var defaultAPILimit = apiLimit{Rate: 2, Burst: 10, Concurrency: 4}
+
+// limiter is a token bucket of an API host, which also clamps requests in
+// flight and pauses all requests when the host asks to back off.
+type limiter struct {
+	inFlight chan struct{}
+
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	paused time.Time // no requests before
+}
+
+// limiters are by API host.
+var limiters sync.Map
+
+func limiterFor(host string) *limiter {
+	if l, ok := limiters.Load(host); ok {
+		return l.(*limiter)
+	}
+	lim, ok := conf.APILimits[host]
+	if !ok {
+		lim = defaultAPILimit
+	}
+	if lim.Rate <= 0 {
+		lim.Rate = defaultAPILimit.Rate
+	}
+	if lim.Burst <= 0 {
+		lim.Burst = 1
+	}
+	if lim.Concurrency <= 0 {
+		lim.Concurrency = 1
+	}
+	l, _ := limiters.LoadOrStore(host, &limiter{
+		inFlight: make(chan struct{}, lim.Concurrency),
+		rate:     lim.Rate,
+		burst:    float64(lim.Burst),
+		tokens:   float64(lim.Burst),
+		last:     time.Now(),
+	})
+	return l.(*limiter)
+}
+
+// wait blocks until a request may be sent.
+func (l *limiter) wait() {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// Take the token now, going into debt if there's none left, so
+	// waiting requests are sent in order.
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if d := l.paused.Sub(now); d > delay {
+		delay = d
+	}
+	l.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// pause stops sending requests until t.
+func (l *limiter) pause(t time.Time) {
+	l.mu.Lock()
+	if t.After(l.paused) {
+		l.paused = t
+	}
+	l.mu.Unlock()
+}
+
+// doAPI sends req to an API within the rate limits of its host. Requests
+// rejected for exceeding the limit are retried when the host allows it.
+func doAPI(req *http.Request) (*http.Response, error) {
+	l := limiterFor(req.URL.Host)
+	l.inFlight <- struct{}{}
+	defer func() { <-l.inFlight }()
+
+	for attempt := 0; ; attempt++ {
+		l.wait()
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		until, limited := rateLimitReset(resp, attempt)
+		if !until.IsZero() {
+			l.pause(until)
+		}
+		if !limited || attempt == maxRetries || req.GetBody == nil && req.Body != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// rateLimitReset returns when the host of resp accepts requests again, or
+// zero time if it doesn't say, and whether it rejected the request for
+// exceeding the rate limit. It honors Retry-After and the X-RateLimit
+// headers of GitHub and GitLab, backing off exponentially with attempt if
+// neither is set.
+func rateLimitReset(resp *http.Response, attempt int) (until time.Time, limited bool) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		remaining = resp.Header.Get("RateLimit-Remaining")
+	}
+	limited = resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusForbidden && remaining == "0" ||
+		resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != ""
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if sec, err := strconv.Atoi(s); err == nil {
+			return time.Now().Add(time.Duration(sec) * time.Second), limited
+		}
+		if t, err := http.ParseTime(s); err == nil {
+			return t, limited
+		}
+	}
+	if remaining == "0" {
+		reset := resp.Header.Get("X-RateLimit-Reset")
+		if reset == "" {
+			reset = resp.Header.Get("RateLimit-Reset")
+		}
+		if sec, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			return time.Unix(sec, 0), limited
+		}
+	}
+	if limited {
+		return time.Now().Add(time.Second << attempt), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// redact returns path of a repo as shown with -redact paths: its codename
+// from config or a short hash of its absolute path, which stays the same
+// across runs. Without -redact it returns path.
+func redact(path string) string {
+	if *redactFlag != "paths" {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	if name, ok := conf.Codenames[abs]; ok {
+		return name
+	}
+	if name, ok := conf.Codenames[filepath.Base(abs)]; ok {
+		return name
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return "repo-" + hex.EncodeToString(sum[:4])
+}
+
+// exportName returns the name of the repo at path as exported to calendars,
+// time trackers and invoices: its repoName, redacted with -redact paths.
+func exportName(path string) string {
+	if *redactFlag == "paths" {
+		return redact(path)
+	}
+	return repoName(path)
+}
+
+// displayStored returns a path of a repo saved in a snapshot or history as
+// shown. Paths saved with -redact paths are already redacted.
+func displayStored(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	return displayPath(path)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactPaths(t *testing.T) {
+	defer func(old string) { *redactFlag = old }(*redactFlag)
+	*redactFlag = "paths"
+
+	tmp := t.TempDir()
+	repo := filepath.Join(tmp, "secretproject")
+	if err := os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	var commits []commitInfo
+	for i := 0; i < 3; i++ {
+		commits = append(commits, commitInfo{
+			repo:    repo,
+			hash:    strings.Repeat(string(rune('a'+i)), 40),
+			author:  "Al",
+			when:    when.Add(time.Duration(i) * 20 * time.Minute),
+			subject: "Change things",
+			changes: 10,
+			files:   []fileChange{{path: "main.go", changes: 10}},
+		})
+	}
+	directories := []directory{{
+		path:    repo,
+		changes: 30,
+		authors: []string{"Al"},
+		files:   []file{{path: "main.go", changes: 30, authors: []string{"Al"}}},
+		commits: commits,
+	}}
+	sessions := workSessions(directories, 2*time.Hour, 30*time.Minute)
+
+	outputs := map[string]func(*bytes.Buffer) error{
+		"summary": func(b *bytes.Buffer) error {
+			b.WriteString(summary(directories))
+			return nil
+		},
+		"email": func(b *bytes.Buffer) error {
+			body, _, err := emailBody(directories, false)
+			b.WriteString(body)
+			return err
+		},
+		"email html": func(b *bytes.Buffer) error {
+			body, _, err := emailBody(directories, true)
+			b.WriteString(body)
+			return err
+		},
+		"hours": func(b *bytes.Buffer) error {
+			reportHours(b, sessions)
+			return nil
+		},
+		"snapshot": func(b *bytes.Buffer) error {
+			return writeSnapshot(b, directories)
+		},
+		"porcelain": func(b *bytes.Buffer) error {
+			reportPorcelain(b, directories)
+			return nil
+		},
+		"json": func(b *bytes.Buffer) error {
+			return json.NewEncoder(b).Encode(newJSONRepo(directories[0], true))
+		},
+		"ics": func(b *bytes.Buffer) error {
+			name := filepath.Join(t.TempDir(), "sessions.ics")
+			if err := writeICS(name, sessions); err != nil {
+				return err
+			}
+			data, err := os.ReadFile(name)
+			b.Write(data)
+			return err
+		},
+		"timetrack": func(b *bytes.Buffer) error {
+			printTimeEntries(b, timeEntries(sessions))
+			return nil
+		},
+		"harvest": func(b *bytes.Buffer) error {
+			entries, _ := harvestEntries(sessions, map[string]harvestProject{"secretproject": {ProjectID: 1, TaskID: 2}})
+			if len(entries) == 0 {
+				t.Error("harvest: repo not mapped by its name")
+			}
+			printHarvestEntries(b, entries)
+			return nil
+		},
+		"invoice": func(b *bytes.Buffer) error {
+			inv, err := newInvoice("2024-03", sessions, invoiceConfig{Rates: map[string]float64{"secretproject": 100}})
+			if err != nil {
+				return err
+			}
+			writeInvoiceMarkdown(b, inv)
+			return htmlInvoice.Execute(b, inv)
+		},
+		"history": func(b *bytes.Buffer) error {
+			h, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+			if err != nil {
+				return err
+			}
+			defer h.Close()
+			if err := h.record(when, "168h", "", directories); err != nil {
+				return err
+			}
+			rows, err := h.db.Query(`SELECT path FROM repo_stats`)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var path string
+				if err := rows.Scan(&path); err != nil {
+					return err
+				}
+				b.WriteString(path + "\n")
+			}
+			return rows.Err()
+		},
+	}
+	for name, output := range outputs {
+		var b bytes.Buffer
+		if err := output(&b); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if b.Len() == 0 {
+			t.Errorf("%s: no output", name)
+		}
+		if strings.Contains(b.String(), "secretproject") {
+			t.Errorf("%s: path of repo not redacted:\n%s", name, b.String())
+		}
+	}
+}
+
+func TestSummaryKeepsOrder(t *testing.T) {
+	directories := []directory{{path: "a", changes: 1}, {path: "b", changes: 2}}
+	if got, want := summary(directories), "3 changes in 2 repos, most in b (2)."; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	if directories[0].path != "a" {
+		t.Error("summary reordered directories")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// urlRewrites returns url.<base>.insteadOf settings of the system, global
+// and repo git config as base by the prefix it replaces.
+func (r gitRepo) urlRewrites() map[string]string {
+	var configs []*gitconfig.Config
+	for _, scope := range []gitconfig.Scope{gitconfig.SystemScope, gitconfig.GlobalScope} {
+		if c, err := gitconfig.LoadConfig(scope); err == nil {
+			configs = append(configs, c)
+		}
+	}
+	if c, err := r.repo.Config(); err == nil {
+		configs = append(configs, c)
+	}
+	rewrites := make(map[string]string)
+	for _, c := range configs {
+		for _, sub := range c.Raw.Section("url").Subsections {
+			for _, prefix := range sub.Options.GetAll("insteadOf") {
+				rewrites[prefix] = sub.Name
+			}
+		}
+	}
+	return rewrites
+}
+
+// rewriteURL replaces the longest prefix of url found in rewrites, like git
+// does.
+func rewriteURL(url string, rewrites map[string]string) string {
+	var longest string
+	for prefix := range rewrites {
+		if strings.HasPrefix(url, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	if longest == "" {
+		return url
+	}
+	return rewrites[longest] + url[len(longest):]
+}
+
+// rewritingStorer is a repo storer whose config has remote URLs rewritten,
+// without changing the config file.
+type rewritingStorer struct {
+	storage.Storer
+	rewrites map[string]string
+}
+
+func (s rewritingStorer) Config() (*gitconfig.Config, error) {
+	c, err := s.Storer.Config()
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range c.Remotes {
+		for i, url := range remote.URLs {
+			remote.URLs[i] = rewriteURL(url, s.rewrites)
+		}
+	}
+	return c, nil
+}
+
+// remoteRepo returns the repo to pull and fetch, which talks to remotes at
+// URLs rewritten by url.<base>.insteadOf settings like git does.
+func (r gitRepo) remoteRepo() (*git.Repository, error) {
+	rewrites := r.urlRewrites()
+	if len(rewrites) == 0 {
+		return r.repo, nil
+	}
+	var worktree billy.Filesystem
+	if w, err := r.repo.Worktree(); err == nil {
+		worktree = w.Filesystem
+	}
+	return git.Open(rewritingStorer{r.repo.Storer, rewrites}, worktree)
+}
+
+// remoteAuth returns how to authenticate to remote: over SSH with the keys
+// and user set for its host in ssh_config, or in ~/.ssh/id_rsa as git, and
+// not at all otherwise, like over HTTPS. A host behind jump hosts is
+// reached through a tunnel whose host key is checked as the host's.
+func remoteAuth(remote *git.Remote) (transport.AuthMethod, error) {
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	ep, err := transport.NewEndpoint(urls[0])
+	if err != nil || ep.Protocol != "ssh" {
+		return nil, err
+	}
+	h := lookupSSHHost(ep.Host)
+	user := ep.User
+	if user == "" {
+		user = h.user
+	}
+	if user == "" {
+		user = "git"
+	}
+	auth, err := h.auth(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.proxyJump) > 0 {
+		if _, err := h.tunnel(); err != nil {
+			return nil, err
+		}
+		knownHosts, err := ssh.NewKnownHostsCallback()
+		if err != nil {
+			return nil, err
+		}
+		addr := h.addr()
+		auth.HostKeyCallback = func(_ string, remote net.Addr, key gossh.PublicKey) error {
+			return knownHosts(addr, remote, key)
+		}
+	}
+	return auth, nil
+}
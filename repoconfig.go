@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"gopkg.in/yaml.v3"
+)
+
+// repoConfigFile is committed in a repo by its owners to control how the
+// repo is measured for everyone who scans it.
+const repoConfigFile = ".workedon.yaml"
+
+// repoConfig holds settings from repoConfigFile.
+type repoConfig struct {
+	// Project names the repo instead of its directory.
+	Project string `yaml:"project"`
+	// Exclude are gitignore patterns of files whose changes don't count.
+	Exclude []string `yaml:"exclude"`
+	// Weights come before those of the config.
+	Weights weights `yaml:"weights"`
+	// JiraProject is the key of the repo's Jira project. Only its issues
+	// get worklogs.
+	JiraProject string `yaml:"jira_project"`
+}
+
+// repoConfigs are configs of the scanned repos by path.
+var repoConfigs sync.Map
+
+// loadRepoConfig reads repoConfigFile of repo at path and remembers it for
+// repoConfigOf. A missing file is an empty config.
+func loadRepoConfig(path string) (repoConfig, error) {
+	var c repoConfig
+	data, err := os.ReadFile(filepath.Join(path, repoConfigFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return c, err
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %v", repoConfigFile, err)
+	}
+	repoConfigs.Store(path, c)
+	return c, nil
+}
+
+// repoConfigOf returns the config of repo at path loaded by the last scan.
+func repoConfigOf(path string) repoConfig {
+	c, _ := repoConfigs.Load(path)
+	rc, _ := c.(repoConfig)
+	return rc
+}
+
+// repoName returns the name of repo at path from config or its project
+// name, which defaults to the name of its directory.
+func repoName(path string) string {
+	if name := configuredName(path); name != "" {
+		return name
+	}
+	if p := repoConfigOf(path).Project; p != "" {
+		return p
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Base(path)
+	}
+	return filepath.Base(abs)
+}
+
+// excludePatterns returns the Exclude patterns of c.
+func (c repoConfig) excludePatterns() []gitignore.Pattern {
+	var ps []gitignore.Pattern
+	for _, p := range c.Exclude {
+		ps = append(ps, parseIgnorePattern(p))
+	}
+	return ps
+}
+
+// repoWeights returns weights of changes in repo at path: those of the repo
+// followed by those of the config.
+func repoWeights(path string) weights {
+	return append(append(weights(nil), repoConfigOf(path).Weights...), conf.Weights...)
+}
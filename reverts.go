@@ -0,0 +1,60 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// revertOf matches the line git revert and hg backout put in the message of
+// a revert, capturing the reverted commit.
+var revertOf = regexp.MustCompile(`(?m)^(?:This reverts commit|Backed out changeset) ([0-9a-f]{7,40})\b`)
+
+// cancelReverts drops reverts together with the commits they revert, so that
+// commit X followed by its revert doesn't count as twice the work. A revert
+// is dropped only if its diff is the inverse of the reverted commit's, which
+// also has to be among commits.
+func cancelReverts(commits []commitInfo) []commitInfo {
+	canceled := make(map[string]bool)
+	for _, r := range commits {
+		m := revertOf.FindStringSubmatch(r.message)
+		if m == nil || canceled[r.hash] {
+			continue
+		}
+		for _, c := range commits {
+			if strings.HasPrefix(c.hash, m[1]) && !canceled[c.hash] && inverse(r, c) {
+				canceled[r.hash], canceled[c.hash] = true, true
+				break
+			}
+		}
+	}
+	if len(canceled) == 0 {
+		return commits
+	}
+
+	var kept []commitInfo
+	for _, c := range commits {
+		if !canceled[c.hash] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// inverse tells whether commit a undoes changes of commit b: it changes the
+// same lines of the same files, adding what b deletes and vice versa.
+func inverse(a, b commitInfo) bool {
+	if len(a.files) != len(b.files) {
+		return false
+	}
+	type counts struct{ changes, net int }
+	bFiles := make(map[string]counts)
+	for _, f := range b.files {
+		bFiles[f.path] = counts{f.changes, f.net}
+	}
+	for _, f := range a.files {
+		if bf, ok := bFiles[f.path]; !ok || bf.changes != f.changes || bf.net != -f.net {
+			return false
+		}
+	}
+	return true
+}
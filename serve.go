@@ -0,0 +1,181 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//go:embed web
+var webFS embed.FS
+
+type jsonReport struct {
+	Since   string     `json:"since"`
+	Until   string     `json:"until,omitempty"`
+	Author  string     `json:"author,omitempty"`
+	Changes int        `json:"changes"`
+	Total   totals     `json:"total"`
+	Repos   []jsonRepo `json:"repos"`
+}
+
+type jsonRepo struct {
+	Path    string     `json:"path"`
+	Changes int        `json:"changes"`
+	Net     int        `json:"net"`
+	Score   float64    `json:"score,omitempty"` // only if weights are set
+	Commits int        `json:"commits"`
+	Authors []string   `json:"authors"`
+	Files   []jsonFile `json:"files,omitempty"`
+	Stale   bool       `json:"stale,omitempty"`
+	// Detached is the default remote branch analyzed instead of a
+	// detached HEAD.
+	Detached string `json:"detached,omitempty"`
+	// Branch is the default branch of a remote analyzed instead of HEAD.
+	Branch string `json:"branch,omitempty"`
+	// PullRequests are pull requests in the window, with -prs.
+	PullRequests *prActivity `json:"pull_requests,omitempty"`
+}
+
+type jsonFile struct {
+	Path    string   `json:"path"`
+	Changes int      `json:"changes"`
+	Net     int      `json:"net"`
+	Authors []string `json:"authors"`
+}
+
+// serve answers /report requests by scanning repos at paths on demand. Query
+// parameters since, author and files override the corresponding opts; from
+// and to (YYYY-MM-DD), which defaults to today, select a date range instead
+// of since. The dashboard is served at /.
+func serve(addr string, paths []string, opts options) error {
+	web, err := fs.Sub(webFS, "web")
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(web)))
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		o := opts
+		o.sinceLastRun = false
+		q := r.URL.Query()
+		if s := q.Get("since"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("since: %v", err), http.StatusBadRequest)
+				return
+			}
+			o.window = d
+		}
+		if to := q.Get("to"); to != "" {
+			t, err := time.ParseInLocation("2006-01-02", to, loc)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("to: %v", err), http.StatusBadRequest)
+				return
+			}
+			o.until = t.AddDate(0, 0, 1) // to the end of the day
+		}
+		if from := q.Get("from"); from != "" {
+			t, err := time.ParseInLocation("2006-01-02", from, loc)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("from: %v", err), http.StatusBadRequest)
+				return
+			}
+			end := o.until
+			if end.IsZero() {
+				end = time.Now()
+			}
+			if !t.Before(end) {
+				http.Error(w, "from: after to", http.StatusBadRequest)
+				return
+			}
+			o.window = end.Sub(t)
+		}
+		if a := q.Get("author"); a != "" {
+			o.author = a
+		}
+		withFiles := false
+		if f := q.Get("files"); f != "" {
+			b, err := strconv.ParseBool(f)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("files: %v", err), http.StatusBadRequest)
+				return
+			}
+			withFiles = b
+		}
+
+		directories := analyze(paths, o, nil)
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false) // keep "Name <email>" readable
+		if err := enc.Encode(newJSONReport(directories, o, withFiles)); err != nil {
+			log.Printf("serve: %v", err)
+		}
+	})
+
+	log.Printf("serving on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func newJSONReport(directories []directory, opts options, withFiles bool) jsonReport {
+	report := jsonReport{
+		Since:  opts.window.String(),
+		Author: opts.author,
+		Repos:  []jsonRepo{},
+	}
+	if !opts.until.IsZero() {
+		report.Until = opts.until.Format(time.RFC3339)
+	}
+
+	report.Total = totalsOf(directories)
+	report.Changes = report.Total.Changes
+
+	sortDirectories(directories)
+	for _, dir := range directories {
+		report.Repos = append(report.Repos, newJSONRepo(dir, withFiles))
+	}
+
+	return report
+}
+
+func newJSONRepo(dir directory, withFiles bool) jsonRepo {
+	abs, err := filepath.Abs(dir.path)
+	if err != nil {
+		abs = dir.path
+	}
+	abs = redact(abs)
+	repo := jsonRepo{
+		Path:     abs,
+		Changes:  dir.changes,
+		Net:      dir.netChanges(""),
+		Commits:  len(dir.commits),
+		Authors:  dir.authorNames(""),
+		Stale:    dir.stale,
+		Detached: dir.detached,
+		Branch:   dir.branch,
+
+		PullRequests: dir.prs,
+	}
+	if len(repoWeights(dir.path)) > 0 {
+		repo.Score = math.Round(dir.score("")*100) / 100
+	}
+	if withFiles {
+		for _, f := range dir.files {
+			repo.Files = append(repo.Files, jsonFile{
+				Path:    f.path,
+				Changes: f.changes,
+				Net:     dir.netChanges(f.path),
+				Authors: dir.authorNames(f.path),
+			})
+		}
+	}
+	return repo
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// sessionLead is the work assumed to precede the first commit of a session.
+const sessionLead = 30 * time.Minute
+
+// session is a stretch of work inferred from closely spaced commits by one
+// author.
+type session struct {
+	author  string
+	start   time.Time
+	end     time.Time
+	repos   []string
+	commits []commitInfo
+}
+
+// commitShare returns the time of the session attributed to each of its
+// commits. The time is split evenly.
+func (s session) commitShare() time.Duration {
+	return s.end.Sub(s.start) / time.Duration(len(s.commits))
+}
+
+// subjects returns the distinct commit subjects of the session.
+func (s session) subjects() []string {
+	var subjects []string
+	for _, c := range s.commits {
+		subjects = append(subjects, c.subject)
+	}
+	return uniq(subjects)
+}
+
+// workSessions infers sessions from commits in directories. Commits of an
+// author that are at most gap apart belong to the same session.
+func workSessions(directories []directory, gap, lead time.Duration) []session {
+	perAuthor := make(map[string][]commitInfo)
+	for _, dir := range directories {
+		for _, c := range dir.commits {
+			perAuthor[c.author] = append(perAuthor[c.author], c)
+		}
+	}
+
+	var sessions []session
+	for author, commits := range perAuthor {
+		sort.Slice(commits, func(i, j int) bool { return commits[i].when.Before(commits[j].when) })
+
+		var s *session
+		for _, c := range commits {
+			if s != nil && c.when.Sub(s.end) > gap {
+				sessions = append(sessions, *s)
+				s = nil
+			}
+			if s == nil {
+				s = &session{author: author, start: c.when.Add(-lead)}
+			}
+			s.end = c.when
+			s.repos = append(s.repos, exportName(c.repo))
+			s.commits = append(s.commits, c)
+		}
+		if s != nil {
+			sessions = append(sessions, *s)
+		}
+	}
+
+	for i := range sessions {
+		sessions[i].repos = uniq(sessions[i].repos)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].start.Before(sessions[j].start) })
+	return sessions
+}
+
+// repoDay is time spent on a repo during one day.
+type repoDay struct {
+	repo     string
+	date     string // YYYY-MM-DD
+	spent    time.Duration
+	subjects []string
+	commits  []string // hashes
+}
+
+// repoDays attributes session time to the repos and days of the session's
+// commits.
+func repoDays(sessions []session) []repoDay {
+	type key struct{ repo, date string }
+	perDay := make(map[key]*repoDay)
+	for _, s := range sessions {
+		share := s.commitShare()
+		for _, c := range s.commits {
+			k := key{c.repo, c.when.In(loc).Format("2006-01-02")}
+			rd, ok := perDay[k]
+			if !ok {
+				rd = &repoDay{repo: c.repo, date: k.date}
+				perDay[k] = rd
+			}
+			rd.spent += share
+			rd.subjects = append(rd.subjects, c.subject)
+			rd.commits = append(rd.commits, c.hash)
+		}
+	}
+
+	var days []repoDay
+	for _, rd := range perDay {
+		rd.subjects = uniq(rd.subjects)
+		days = append(days, *rd)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].date != days[j].date {
+			return days[i].date < days[j].date
+		}
+		return days[i].repo < days[j].repo
+	})
+	return days
+}
+
+// reportHours prints hours estimated from work sessions per day and repo.
+func reportHours(w io.Writer, sessions []session) {
+	days := repoDays(sessions)
+	if len(days) == 0 {
+		return
+	}
+
+	const format = "%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "DATE", "PATH", "HOURS")
+	var total time.Duration
+	for _, rd := range days {
+		fmt.Fprintf(tw, format, rd.date, displayPath(rd.repo), fmt.Sprintf("%.1f", rd.spent.Hours()))
+		total += rd.spent
+	}
+	fmt.Fprintf(tw, format, "TOTAL", "", fmt.Sprintf("%.1f", total.Hours()))
+	tw.Flush()
+}
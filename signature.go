@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// verifySignature reports whether the GPG or SSH signature of commit h is
+// valid. It uses git verify-commit so that the user's gpg keyring and
+// gpg.ssh.allowedSignersFile are honored.
+func verifySignature(repo *git.Repository, h plumbing.Hash) bool {
+	s, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return false
+	}
+	return verifyCommit(s.Filesystem().Root(), h.String())
+}
+
+// verifyCommit is like verifySignature for commit hash in repo at gitDir.
+func verifyCommit(gitDir, hash string) bool {
+	cmd := exec.Command("git", "--git-dir", gitDir, "verify-commit", hash)
+	return cmd.Run() == nil
+}
+
+// signoffTrailer matches a Signed-off-by trailer line capturing the name and
+// email.
+var signoffTrailer = regexp.MustCompile(`(?m)^Signed-off-by:\s*(.*?)\s*<([^>]*)>\s*$`)
+
+// signedOffByAuthor reports whether commit message msg has a Signed-off-by
+// trailer of its author, as required by the Developer Certificate of Origin.
+func signedOffByAuthor(msg, author, email string) bool {
+	for _, m := range signoffTrailer.FindAllStringSubmatch(msg, -1) {
+		if strings.EqualFold(m[2], email) || sameAuthor(m[1], author) {
+			return true
+		}
+	}
+	return false
+}
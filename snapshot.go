@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// snapshot is a saved report that can be compared with a later one.
+type snapshot struct {
+	Time  time.Time      `json:"time"`
+	Total totals         `json:"total"`
+	Repos []snapshotRepo `json:"repos"`
+}
+
+type snapshotRepo struct {
+	Path    string   `json:"path"`
+	Changes int      `json:"changes"`
+	Authors []string `json:"authors"`
+}
+
+func writeSnapshot(w io.Writer, directories []directory) error {
+	snap := snapshot{Time: time.Now(), Total: totalsOf(directories)}
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			return err
+		}
+		snap.Repos = append(snap.Repos, snapshotRepo{
+			Path:    redact(abs),
+			Changes: dir.changes,
+			Authors: dir.authorNames(""),
+		})
+	}
+	sort.Slice(snap.Repos, func(i, j int) bool { return snap.Repos[i].Path < snap.Repos[j].Path })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
+
+func readSnapshot(name string) (*snapshot, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &snap, nil
+}
+
+// repoDiff is the change in activity of a repo between a snapshot and now.
+type repoDiff struct {
+	path    string
+	before  int
+	now     int
+	display string
+}
+
+// reportDiff prints repos whose changes differ from the snapshot, those that
+// became more active first.
+func reportDiff(snap *snapshot, directories []directory) {
+	diffs := make(map[string]*repoDiff)
+	for _, r := range snap.Repos {
+		diffs[r.Path] = &repoDiff{path: r.Path, before: r.Changes, display: displayStored(r.Path)}
+	}
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			abs = dir.path
+		}
+		abs = redact(abs)
+		d, ok := diffs[abs]
+		if !ok {
+			d = &repoDiff{path: abs}
+			diffs[abs] = d
+		}
+		d.now = dir.changes
+		d.display = displayPath(dir.path)
+	}
+
+	var list []*repoDiff
+	for _, d := range diffs {
+		if d.now != d.before {
+			list = append(list, d)
+		}
+	}
+	if len(list) == 0 {
+		return
+	}
+	sort.Slice(list, func(i, j int) bool {
+		di, dj := list[i].now-list[i].before, list[j].now-list[j].before
+		if di != dj {
+			return di > dj
+		}
+		return list[i].path < list[j].path
+	})
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(os.Stdout)
+	fmt.Fprintf(tw, format, "PATH", "BEFORE", "NOW", "DELTA")
+	for _, d := range list {
+		fmt.Fprintf(tw, format, d.display, d.before, d.now, fmt.Sprintf("%+d", d.now-d.before))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	osuser "os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/kevinburke/ssh_config"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func init() {
+	// Let go-git dial hosts at their HostName and Port, or through the
+	// tunnel to a host behind a jump host.
+	ssh.DefaultSSHConfig = sshConfigReader{}
+}
+
+// sshConfigs are the parsed ~/.ssh/config and /etc/ssh/ssh_config, in the
+// order their settings take precedence.
+var sshConfigs struct {
+	once    sync.Once
+	configs []*ssh_config.Config
+}
+
+func loadSSHConfigs() []*ssh_config.Config {
+	sshConfigs.once.Do(func() {
+		var files []string
+		if home, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(home, ".ssh", "config"))
+		}
+		files = append(files, filepath.Join("/", "etc", "ssh", "ssh_config"))
+		for _, file := range files {
+			f, err := os.Open(file)
+			if err != nil {
+				continue
+			}
+			if c, err := ssh_config.Decode(f); err == nil {
+				sshConfigs.configs = append(sshConfigs.configs, c)
+			}
+			f.Close()
+		}
+	})
+	return sshConfigs.configs
+}
+
+// sshOptions returns values of keyword for host from ssh_config, the first
+// one taking precedence.
+func sshOptions(host, keyword string) (values []string) {
+	// ssh_config panics on Match blocks, which are then ignored.
+	defer func() {
+		if recover() != nil {
+			values = nil
+		}
+	}()
+	for _, c := range loadSSHConfigs() {
+		v, _ := c.GetAll(host, keyword)
+		values = append(values, v...)
+	}
+	return values
+}
+
+// sshOption returns the value of keyword for host from ssh_config, or "".
+func sshOption(host, keyword string) string {
+	if values := sshOptions(host, keyword); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// sshHost is how to reach a host named in a remote URL as set for it in
+// ssh_config.
+type sshHost struct {
+	alias         string
+	hostname      string
+	port          int
+	user          string
+	identityFiles []string
+	proxyJump     []string // jump hosts in the order they're connected
+}
+
+func lookupSSHHost(alias string) sshHost {
+	h := sshHost{
+		alias:    alias,
+		hostname: alias,
+		port:     22,
+		user:     sshOption(alias, "User"),
+	}
+	if hostname := sshOption(alias, "HostName"); hostname != "" {
+		h.hostname = strings.ReplaceAll(hostname, "%h", alias)
+	}
+	if port, err := strconv.Atoi(sshOption(alias, "Port")); err == nil {
+		h.port = port
+	}
+	for _, file := range sshOptions(alias, "IdentityFile") {
+		h.identityFiles = append(h.identityFiles, expandHome(file))
+	}
+	if jump := sshOption(alias, "ProxyJump"); jump != "" && jump != "none" {
+		h.proxyJump = strings.Split(jump, ",")
+	}
+	return h
+}
+
+// parseJumpHost returns a jump host given like [user@]host[:port].
+func parseJumpHost(jump string) sshHost {
+	user, hostport, ok := strings.Cut(jump, "@")
+	if !ok {
+		user, hostport = "", jump
+	}
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = hostport, ""
+	}
+	h := lookupSSHHost(host)
+	if user != "" {
+		h.user = user
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		h.port = p
+	}
+	return h
+}
+
+// configured tells whether ssh_config changes where to connect to reach h.
+func (h sshHost) configured() bool {
+	return h.hostname != h.alias || h.port != 22 || len(h.proxyJump) > 0
+}
+
+func (h sshHost) addr() string {
+	return net.JoinHostPort(h.hostname, strconv.Itoa(h.port))
+}
+
+// auth returns the keys from the identity files of h, or from
+// ~/.ssh/id_rsa if it has none, to authenticate as user.
+func (h sshHost) auth(user string) (*ssh.PublicKeysCallback, error) {
+	files := h.identityFiles
+	if len(files) == 0 {
+		files = []string{expandHome("~/.ssh/id_rsa")}
+	}
+	var signers []gossh.Signer
+	var firstErr error
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err == nil {
+			var signer gossh.Signer
+			if signer, err = gossh.ParsePrivateKey(b); err == nil {
+				signers = append(signers, signer)
+				continue
+			}
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(signers) == 0 {
+		return nil, firstErr
+	}
+	return &ssh.PublicKeysCallback{
+		User:     user,
+		Callback: func() ([]gossh.Signer, error) { return signers, nil },
+	}, nil
+}
+
+// dialJumps connects to the last of jump hosts through the ones before it.
+func dialJumps(jumps []string) (*gossh.Client, error) {
+	var client *gossh.Client
+	for _, jump := range jumps {
+		h := parseJumpHost(jump)
+		user := h.user
+		if user == "" {
+			if u, err := osuser.Current(); err == nil {
+				user = u.Username
+			}
+		}
+		auth, err := h.auth(user)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %v", h.alias, err)
+		}
+		config, err := auth.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		// Jump hosts are dialed at their own address, which known_hosts
+		// lists them by.
+		if config.HostKeyCallback, err = ssh.NewKnownHostsCallback(); err != nil {
+			return nil, err
+		}
+		var conn net.Conn
+		if client == nil {
+			conn, err = net.Dial("tcp", h.addr())
+		} else {
+			conn, err = client.Dial("tcp", h.addr())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %v", h.alias, err)
+		}
+		c, chans, reqs, err := gossh.NewClientConn(conn, h.addr(), config)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("jump host %s: %v", h.alias, err)
+		}
+		client = gossh.NewClient(c, chans, reqs)
+	}
+	return client, nil
+}
+
+// tunnels are local addresses forwarding to hosts behind jump hosts, by
+// alias. They stay open until workedon exits.
+var tunnels sync.Map
+
+type tunnel struct {
+	once sync.Once
+	addr *net.TCPAddr
+	err  error
+}
+
+// tunnel returns the local address forwarding to h through its last jump
+// host, opening it on first use.
+func (h sshHost) tunnel() (*net.TCPAddr, error) {
+	v, _ := tunnels.LoadOrStore(h.alias, &tunnel{})
+	t := v.(*tunnel)
+	t.once.Do(func() {
+		var client *gossh.Client
+		client, t.err = dialJumps(h.proxyJump)
+		if t.err != nil {
+			return
+		}
+		var ln net.Listener
+		ln, t.err = net.Listen("tcp", "127.0.0.1:0")
+		if t.err != nil {
+			client.Close()
+			return
+		}
+		t.addr = ln.Addr().(*net.TCPAddr)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go forward(conn, client, h.addr())
+			}
+		}()
+	})
+	return t.addr, t.err
+}
+
+// forward copies between local and addr reached by client until either
+// closes.
+func forward(local net.Conn, client *gossh.Client, addr string) {
+	defer local.Close()
+	remote, err := client.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(remote, local); done <- struct{}{} }()
+	go func() { io.Copy(local, remote); done <- struct{}{} }()
+	<-done
+}
+
+// sshConfigReader tells go-git where to connect to reach a host.
+type sshConfigReader struct{}
+
+func (sshConfigReader) Get(alias, keyword string) string {
+	h := lookupSSHHost(alias)
+	if !h.configured() {
+		// Connect as the URL says.
+		return ""
+	}
+	hostname, port := h.hostname, h.port
+	if len(h.proxyJump) > 0 {
+		if addr, err := h.tunnel(); err == nil {
+			hostname, port = addr.IP.String(), addr.Port
+		}
+	}
+	switch strings.ToLower(keyword) {
+	case "hostname":
+		return hostname
+	case "port":
+		return strconv.Itoa(port)
+	}
+	return sshOption(alias, keyword)
+}
+
+// expandHome replaces leading ~ or %d in path with the home directory.
+func expandHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	for _, prefix := range []string{"~", "%d"} {
+		if strings.HasPrefix(path, prefix) {
+			return filepath.Join(home, path[len(prefix):])
+		}
+	}
+	return path
+}
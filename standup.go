@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// previousWorkday returns the start of the weekday before now's day, so on
+// Mondays it's the start of Friday.
+func previousWorkday(now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for {
+		day = day.AddDate(0, 0, -1)
+		if !isWeekend(day) {
+			return day
+		}
+	}
+}
+
+// reportStandup prints subjects of commits per repo, oldest first.
+func reportStandup(w io.Writer, directories []directory) {
+	sort.Sort(byDirPath(directories))
+	for i, dir := range directories {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, displayPath(dir.path))
+		commits := append([]commitInfo(nil), dir.commits...)
+		sort.Slice(commits, func(i, j int) bool { return commits[i].when.Before(commits[j].when) })
+		for _, c := range commits {
+			fmt.Fprintf(w, "  %s %s", c.when.In(loc).Format("Mon 15:04"), c.subject)
+			if *author == "" {
+				fmt.Fprintf(w, " (%s)", c.author)
+			}
+			if c.generated {
+				fmt.Fprint(w, " [generated]")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
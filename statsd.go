@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pushStatsd sends total and per-repo changes and the scan duration as
+// StatsD gauges and timers over UDP to addr.
+func pushStatsd(addr string, directories []directory, scanDuration time.Duration) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var total int
+	var lines []string
+	for _, dir := range directories {
+		total += dir.changes
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			abs = dir.path
+		}
+		abs = redact(abs)
+		lines = append(lines, fmt.Sprintf("workedon.repo.%s.changes:%d|g", statsdName(abs), dir.changes))
+	}
+	lines = append(lines,
+		fmt.Sprintf("workedon.changes:%d|g", total),
+		fmt.Sprintf("workedon.scan_duration:%d|ms", scanDuration.Milliseconds()),
+	)
+
+	for _, line := range lines {
+		if _, err := conn.Write([]byte(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statsdName turns a path into dot-separated metric name components, e.g.
+// /home/me/git/foo.go becomes home.me.git.foo_go.
+func statsdName(path string) string {
+	var parts []string
+	for _, p := range strings.Split(filepath.ToSlash(path), "/") {
+		if p == "" {
+			continue
+		}
+		parts = append(parts, strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+				return r
+			}
+			return '_'
+		}, p))
+	}
+	return strings.Join(parts, ".")
+}
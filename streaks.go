@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+type streak struct {
+	current      int // days, ending today or yesterday
+	longest      int // days
+	longestStart time.Time
+	longestEnd   time.Time
+}
+
+// commitDays returns the distinct days in the -tz timezone with commits, oldest first.
+func commitDays(commits []commitInfo) []time.Time {
+	seen := make(map[time.Time]bool)
+	var days []time.Time
+	for _, c := range commits {
+		t := c.when.In(loc)
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// streaks computes the streaks of consecutive days with commits as of today.
+func streaks(commits []commitInfo, today time.Time) streak {
+	var s streak
+	days := commitDays(commits)
+	run := 0
+	for i, d := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > s.longest {
+			s.longest = run
+			s.longestStart = d.AddDate(0, 0, -(run - 1))
+			s.longestEnd = d
+		}
+	}
+
+	if len(days) > 0 {
+		t := today.In(loc)
+		today = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		last := days[len(days)-1]
+		if last.Equal(today) || last.AddDate(0, 0, 1).Equal(today) {
+			s.current = run
+		}
+	}
+	return s
+}
+
+// reportStreaks prints streaks of days with commits across all repos and
+// per repo.
+func reportStreaks(w io.Writer, directories []directory) {
+	if len(directories) == 0 {
+		return
+	}
+	sort.Slice(directories, func(i, j int) bool { return directories[i].path < directories[j].path })
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "PATH", "CURRENT", "LONGEST", "LONGEST STREAK")
+	row := func(path string, commits []commitInfo) {
+		s := streaks(commits, time.Now())
+		span := "-"
+		if s.longest > 0 {
+			span = s.longestStart.Format("2006-01-02") + " - " + s.longestEnd.Format("2006-01-02")
+		}
+		fmt.Fprintf(tw, format, path, s.current, s.longest, span)
+	}
+
+	var all []commitInfo
+	for _, dir := range directories {
+		all = append(all, dir.commits...)
+	}
+	row("*", all)
+	for _, dir := range directories {
+		row(displayPath(dir.path), dir.commits)
+	}
+	tw.Flush()
+}
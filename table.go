@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// termWidth is the width of the terminal stdout is connected to, or 0.
+var termWidth int
+
+// table is a report aligned by tabwriter and colored after alignment, so
+// the escape sequences don't count towards column widths.
+type table struct {
+	header     []string
+	rows       [][]string
+	styles     []string
+	authorsCol int // index of the column listing authors, -1 for none
+	whyCol     int // index of the column with commit subjects, 0 for none
+}
+
+// add adds a row printed in style, which may be empty.
+func (t *table) add(style string, cells ...string) {
+	t.rows = append(t.rows, cells)
+	t.styles = append(t.styles, style)
+}
+
+// fit truncates the commit subjects, authors and paths in the first column,
+// in this order, so that rows are at most width wide.
+func (t *table) fit(width int) {
+	const minWidth, gap = 12, 2
+	widths := make([]int, len(t.header))
+	for _, r := range append([][]string{t.header}, t.rows...) {
+		for i, c := range r {
+			if n := displayWidth(c); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	excess := gap * (len(widths) - 1)
+	for _, w := range widths {
+		excess += w
+	}
+	excess -= width
+	cols := []int{t.authorsCol, 0}
+	if t.whyCol > 0 {
+		cols = append([]int{t.whyCol}, cols...)
+	}
+	for _, col := range cols {
+		if excess <= 0 || col < 0 || widths[col] <= minWidth {
+			continue
+		}
+		max := widths[col] - excess
+		if max < minWidth {
+			max = minWidth
+		}
+		excess -= widths[col] - max
+		for _, r := range t.rows {
+			if col == 0 {
+				r[col] = truncateLeft(r[col], max)
+			} else {
+				r[col] = truncate(r[col], max)
+			}
+		}
+	}
+}
+
+// truncate shortens s to at most n columns wide, marking the cut with an
+// ellipsis. Combining marks stay with the characters they modify.
+func truncate(s string, n int) string {
+	if displayWidth(s) <= n {
+		return s
+	}
+	var w, end int
+	for offset, r := range s {
+		rw := runeWidth(r)
+		if rw > 0 && w+rw > n-1 {
+			break
+		}
+		w += rw
+		end = offset + utf8.RuneLen(r)
+	}
+	return s[:end] + "…"
+}
+
+// truncateLeft is like truncate but keeps the end of s.
+func truncateLeft(s string, n int) string {
+	if displayWidth(s) <= n {
+		return s
+	}
+	var w int
+	start := len(s)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(s[:start])
+		rw := runeWidth(r)
+		if rw > 0 && w+rw > n-1 {
+			break
+		}
+		w += rw
+		start -= size
+	}
+	// Don't start with marks of a character cut off.
+	for start < len(s) {
+		r, size := utf8.DecodeRuneInString(s[start:])
+		if runeWidth(r) > 0 {
+			break
+		}
+		start += size
+	}
+	return "…" + s[start:]
+}
+
+// write writes t to w, in color if enabled and w is stdout. Rows too wide
+// for the terminal are truncated.
+func (t *table) write(w io.Writer) {
+	if termWidth > 0 && w == io.Writer(os.Stdout) {
+		t.fit(termWidth)
+	}
+
+	var buf bytes.Buffer
+	tw := newTableWriter(&buf)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, r := range t.rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	tw.Flush()
+
+	if !colorOn || w != io.Writer(os.Stdout) {
+		w.Write(buf.Bytes())
+		return
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	col := -1 // in columns of the terminal
+	if t.authorsCol >= 0 {
+		col = displayWidth(lines[0][:strings.Index(lines[0], t.header[t.authorsCol])])
+	}
+	fmt.Fprint(w, bold+strings.TrimSuffix(lines[0], "\n")+reset+"\n")
+	for i, r := range t.rows {
+		line := strings.TrimSuffix(lines[i+1], "\n")
+		offset := widthOffset(line, col)
+		if col >= 0 && offset >= 0 && offset+len(r[t.authorsCol]) <= len(line) {
+			var names []string
+			for _, name := range strings.Split(r[t.authorsCol], ", ") {
+				if name != "" && !strings.HasPrefix(name, "+") {
+					name = colorAuthor(name) + t.styles[i]
+				}
+				names = append(names, name)
+			}
+			end := offset + len(r[t.authorsCol])
+			line = line[:offset] + strings.Join(names, ", ") + line[end:]
+		}
+		if t.styles[i] != "" {
+			line = t.styles[i] + line + reset
+		}
+		fmt.Fprintln(w, line)
+	}
+}
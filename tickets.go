@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ghIssuePattern matches GitHub issue references like GH-123.
+const ghIssuePattern = `\bGH-[1-9][0-9]*\b`
+
+var ghIssue = regexp.MustCompile(ghIssuePattern)
+
+// defaultTicketPatterns match Jira keys, GitHub issue references like #1234
+// and GH-123.
+var defaultTicketPatterns = []string{jiraKeyPattern, ghIssuePattern, issueNumberPattern}
+
+// ticketPatterns compiles the ticket patterns from config, or the default
+// ones if there are none.
+func ticketPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = defaultTicketPatterns
+	}
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("ticket pattern: %v", err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// tickets returns the distinct ticket references in the message of c. Matches
+// of jiraKeyPattern count only if they are in jiraProjects of the repo of c.
+func tickets(c commitInfo, patterns []*regexp.Regexp) []string {
+	var ids []string
+	for _, re := range patterns {
+		for _, id := range re.FindAllString(c.message, -1) {
+			if re.String() == jiraKeyPattern && !inJiraProjects(id, jiraProjects(c.repo)) {
+				continue
+			}
+			ids = append(ids, id)
+		}
+	}
+	return uniq(ids)
+}
+
+type ticket struct {
+	id      string
+	changes int
+	commits int
+	repos   []string
+	authors []string
+}
+
+// reportTickets prints changes grouped by the tickets referenced in commit
+// messages. A commit referencing several tickets counts for each of them.
+func reportTickets(w io.Writer, directories []directory, patterns []*regexp.Regexp) {
+	const none = "none"
+	perTicket := make(map[string]*ticket)
+	var totalChanges, totalCommits int
+	for _, dir := range directories {
+		for _, c := range dir.commits {
+			totalChanges += c.changes
+			totalCommits++
+			ids := tickets(c, patterns)
+			if len(ids) == 0 {
+				ids = []string{none}
+			}
+			for _, id := range ids {
+				t, ok := perTicket[id]
+				if !ok {
+					t = &ticket{id: id}
+					perTicket[id] = t
+				}
+				t.changes += c.changes
+				t.commits++
+				t.repos = append(t.repos, displayPath(dir.path))
+				t.authors = append(t.authors, c.author)
+			}
+		}
+	}
+	if len(perTicket) == 0 {
+		return
+	}
+
+	var list []*ticket
+	for _, t := range perTicket {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].id == none) != (list[j].id == none) {
+			return list[j].id == none
+		}
+		if list[i].changes != list[j].changes {
+			return list[i].changes > list[j].changes
+		}
+		return list[i].id < list[j].id
+	})
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "TICKET", "CHANGES", "REPOS", "AUTHORS")
+	for _, t := range list {
+		changes := changesCell(t.changes, t.commits, totalChanges, totalCommits)
+		fmt.Fprintf(tw, format, t.id, changes, strings.Join(uniq(t.repos), ", "), strings.Join(uniq(t.authors), ", "))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTickets(t *testing.T) {
+	defer func(old []string) { conf.Jira.Projects = old }(conf.Jira.Projects)
+	patterns, err := ticketPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := "Fix GH-12 and BILL-3 (#45), move to UTF-8 and SHA-256, see ISO-8601 and X-1"
+
+	tests := []struct {
+		projects []string
+		want     []string
+	}{
+		{nil, []string{"GH-12", "#45"}},
+		{[]string{"BILL"}, []string{"BILL-3", "GH-12", "#45"}},
+	}
+	for _, tt := range tests {
+		conf.Jira.Projects = tt.projects
+		if got := tickets(commitInfo{message: msg}, patterns); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tickets with Jira projects %v = %v, want %v", tt.projects, got, tt.want)
+		}
+	}
+}
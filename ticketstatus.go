@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// advancedTicket is a ticket commits in the window referenced, with its
+// title and status looked up in Jira or GitHub Issues.
+type advancedTicket struct {
+	project string // Jira project key or GitHub owner/repo
+	id      string
+	title   string
+	status  string
+	changes int
+	commits int
+}
+
+// advancedTickets returns tickets referenced by commits of directories that
+// can be looked up: Jira issues if Jira is set in config, and issues like
+// #123 or GH-123 of repos on GitHub. Tickets that can't be found are left out.
+func advancedTickets(directories []directory, patterns []*regexp.Regexp) []advancedTicket {
+	type key struct{ project, id string }
+	perTicket := make(map[key]*advancedTicket)
+	var keys []key
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			abs = dir.path
+		}
+		api, repo, onGitHub := githubRepo(remoteURL(abs))
+		for _, c := range dir.commits {
+			for _, id := range tickets(c, patterns) {
+				var k key
+				var lookup func() (title, status string, err error)
+				switch {
+				case ghIssue.MatchString(id) && !inJiraProjects(id, jiraProjects(c.repo)) && onGitHub:
+					k = key{project: repo, id: id}
+					lookup = func() (string, string, error) { return githubIssue(api, repo, strings.TrimPrefix(id, "GH-")) }
+				case jiraKey.MatchString(id) && conf.Jira.URL != "":
+					k = key{project: id[:strings.Index(id, "-")], id: id}
+					lookup = func() (string, string, error) { return jiraIssue(id) }
+				case issueNumber.MatchString(id) && onGitHub:
+					k = key{project: repo, id: id}
+					lookup = func() (string, string, error) { return githubIssue(api, repo, strings.TrimPrefix(id, "#")) }
+				default:
+					continue
+				}
+				t, ok := perTicket[k]
+				if !ok {
+					t = &advancedTicket{project: k.project, id: k.id}
+					perTicket[k] = t
+					keys = append(keys, k)
+					if t.title, t.status, err = lookup(); err != nil {
+						log.Printf("looking up ticket %s: %v", id, err)
+					}
+				}
+				t.changes += c.changes
+				t.commits++
+			}
+		}
+	}
+
+	var list []advancedTicket
+	for _, k := range keys {
+		if t := perTicket[k]; t.status != "" {
+			list = append(list, *t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].project != list[j].project {
+			return list[i].project < list[j].project
+		}
+		if list[i].changes != list[j].changes {
+			return list[i].changes > list[j].changes
+		}
+		return list[i].id < list[j].id
+	})
+	return list
+}
+
+// jiraIssue returns the summary and status of Jira issue with key.
+func jiraIssue(key string) (title, status string, err error) {
+	var issue struct {
+		Fields struct {
+			Summary string `json:"summary"`
+			Status  struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	url := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,status", strings.TrimSuffix(conf.Jira.URL, "/"), key)
+	if _, err := getJSON(url, &issue, jiraAuth(conf.Jira)); err != nil {
+		return "", "", err
+	}
+	return issue.Fields.Summary, issue.Fields.Status.Name, nil
+}
+
+// githubIssue returns the title and state, open or closed, of issue or pull
+// request number of repo on GitHub.
+func githubIssue(api, repo, number string) (title, state string, err error) {
+	var issue struct {
+		Title string `json:"title"`
+		State string `json:"state"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%s", api, repo, number)
+	if _, err := getJSON(url, &issue, githubAuth); err != nil {
+		return "", "", err
+	}
+	return issue.Title, issue.State, nil
+}
+
+// reportAdvancedTickets prints tickets under the projects they belong to.
+func reportAdvancedTickets(w io.Writer, list []advancedTicket) {
+	if len(list) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "TICKETS ADVANCED", "STATUS", "CHANGES", "COMMITS", "TITLE")
+	var project string
+	for _, t := range list {
+		if t.project != project {
+			project = t.project
+			fmt.Fprintf(tw, format, project, "", "", "", "")
+		}
+		fmt.Fprintf(tw, format, "  "+t.id, t.status, formatInt(t.changes), t.commits, t.title)
+	}
+	tw.Flush()
+}
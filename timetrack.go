@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// timeEntry is a time tracking entry created from a work session.
+type timeEntry struct {
+	start       time.Time
+	end         time.Time
+	description string
+	commits     []string // hashes
+}
+
+func timeEntries(sessions []session) []timeEntry {
+	var entries []timeEntry
+	for _, s := range sessions {
+		entries = append(entries, timeEntry{
+			start:       s.start,
+			end:         s.end,
+			description: strings.Join(s.repos, ", ") + ": " + strings.Join(s.subjects(), "; "),
+			commits:     hashes(s.commits),
+		})
+	}
+	return entries
+}
+
+// exportTimeEntries creates time entries in Toggl or Clockify and marks their
+// commits as posted. If dryRun is true it only prints what would be created.
+func exportTimeEntries(service string, entries []timeEntry, posted postedCommits, dryRun bool) error {
+	var create func(timeEntry) error
+	switch service {
+	case "toggl":
+		create = createTogglEntry
+	case "clockify":
+		create = createClockifyEntry
+	default:
+		return fmt.Errorf("-timetrack: want toggl or clockify, got %q", service)
+	}
+
+	if dryRun {
+		printTimeEntries(os.Stdout, entries)
+		return nil
+	}
+	for _, e := range entries {
+		if err := create(e); err != nil {
+			return err
+		}
+		posted.mark(service, e.commits)
+	}
+	return nil
+}
+
+func printTimeEntries(w io.Writer, entries []timeEntry) {
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "START", "END", "DURATION", "DESCRIPTION")
+	for _, e := range entries {
+		fmt.Fprintf(tw, format, e.start.In(loc).Format("2006-01-02 15:04"), e.end.In(loc).Format("15:04"),
+			e.end.Sub(e.start).Round(time.Minute), e.description)
+	}
+	tw.Flush()
+}
+
+func createTogglEntry(e timeEntry) error {
+	c := conf.Toggl
+	if c.Token == "" || c.WorkspaceID == 0 {
+		return fmt.Errorf("toggl.token and toggl.workspace_id must be set in config")
+	}
+	body := map[string]interface{}{
+		"created_with": "workedon",
+		"description":  e.description,
+		"start":        e.start.UTC().Format(time.RFC3339),
+		"duration":     int(e.end.Sub(e.start).Seconds()),
+		"workspace_id": c.WorkspaceID,
+	}
+	if c.ProjectID != 0 {
+		body["project_id"] = c.ProjectID
+	}
+	url := fmt.Sprintf("https://api.track.toggl.com/api/v9/workspaces/%d/time_entries", c.WorkspaceID)
+	return postJSON(url, body, func(r *http.Request) { r.SetBasicAuth(c.Token, "api_token") })
+}
+
+func createClockifyEntry(e timeEntry) error {
+	c := conf.Clockify
+	if c.APIKey == "" || c.WorkspaceID == "" {
+		return fmt.Errorf("clockify.api_key and clockify.workspace_id must be set in config")
+	}
+	body := map[string]interface{}{
+		"description": e.description,
+		"start":       e.start.UTC().Format(time.RFC3339),
+		"end":         e.end.UTC().Format(time.RFC3339),
+	}
+	if c.ProjectID != "" {
+		body["projectId"] = c.ProjectID
+	}
+	url := fmt.Sprintf("https://api.clockify.me/api/v1/workspaces/%s/time-entries", c.WorkspaceID)
+	return postJSON(url, body, func(r *http.Request) { r.Header.Set("X-Api-Key", c.APIKey) })
+}
+
+// postJSON posts body encoded as JSON to url. auth sets credentials on the
+// request.
+func postJSON(url string, body interface{}, auth func(*http.Request)) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	auth(req)
+
+	resp, err := doAPI(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// xssiPrefix precedes JSON responses of Gerrit to keep browsers from running
+// them as scripts.
+const xssiPrefix = ")]}'"
+
+// getJSON decodes the JSON response to a GET of url into v and returns the
+// URL of the next page from the Link header, or "" if it's the last one.
+// auth sets credentials on the request.
+func getJSON(url string, v interface{}, auth func(*http.Request)) (next string, err error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	auth(req)
+
+	resp, err := doAPI(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("%s: %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+	body := bufio.NewReader(resp.Body)
+	if prefix, _ := body.Peek(len(xssiPrefix)); string(prefix) == xssiPrefix {
+		body.Discard(len(xssiPrefix))
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return "", fmt.Errorf("%s: %v", url, err)
+	}
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>"), nil
+		}
+	}
+	return "", nil
+}
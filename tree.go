@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// node is a directory (or with -files a file) in the tree report. Its
+// changes include those of all nodes below it.
+type node struct {
+	name     string
+	changes  int
+	children map[string]*node
+}
+
+// add adds changes to n and to the nodes along the path elems below it.
+func (n *node) add(elems []string, changes int) {
+	n.changes += changes
+	if len(elems) == 0 {
+		return
+	}
+	if n.children == nil {
+		n.children = make(map[string]*node)
+	}
+	child, ok := n.children[elems[0]]
+	if !ok {
+		child = &node{name: elems[0]}
+		n.children[elems[0]] = child
+	}
+	child.add(elems[1:], changes)
+}
+
+// sorted returns children of n ordered by the -sort key, reversed if
+// -reverse is set.
+func (n *node) sorted() []*node {
+	var nodes []*node
+	for _, c := range n.children {
+		nodes = append(nodes, c)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if *sortBy == "changes" && nodes[i].changes != nodes[j].changes {
+			return nodes[i].changes > nodes[j].changes
+		}
+		return nodes[i].name < nodes[j].name
+	})
+	if *reverse {
+		reverseSlice(nodes)
+	}
+	return nodes
+}
+
+// commonDir returns the deepest directory containing all paths, or "" if
+// they are on different volumes, like C: and D: on Windows.
+func commonDir(paths []string) string {
+	dir := filepath.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for !inDir(p, dir) {
+			if dir == filepath.Dir(dir) {
+				return ""
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// inDir tells whether path is below dir. On Windows case doesn't matter.
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// reportTree prints changes as a tree mirroring the directory structure
+// of the repos, with changes rolled up at every level.
+func reportTree(w io.Writer, directories []directory) {
+	if len(directories) == 0 {
+		return
+	}
+
+	var paths []string
+	for _, dir := range directories {
+		abs, err := filepath.Abs(dir.path)
+		if err != nil {
+			abs = dir.path
+		}
+		abs = redact(abs)
+		paths = append(paths, abs)
+	}
+	root := &node{name: commonDir(paths)}
+	for i, dir := range directories {
+		elems := pathElems(root.name, paths[i])
+		if !*files {
+			root.add(elems, dir.changes)
+			continue
+		}
+		for _, f := range dir.files {
+			root.add(append(elems[:len(elems):len(elems)], strings.Split(f.path, "/")...), f.changes)
+		}
+	}
+
+	tw := newTableWriter(w)
+	fmt.Fprintln(tw, "PATH\tCHANGES")
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		name := n.name
+		if len(n.children) > 0 && depth > 0 {
+			name += "/"
+		}
+		fmt.Fprintf(tw, "%s%s\t%d\n", strings.Repeat("  ", depth), name, n.changes)
+		for _, c := range n.sorted() {
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+	tw.Flush()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// trendPeriods returns the starts of the periods, months or weeks if weekly,
+// of the last n months until now, preceded by extra periods.
+func trendPeriods(n int, weekly bool, extra int, now time.Time) []time.Time {
+	first := time.Date(now.Year(), now.Month()-time.Month(n-1), 1, 0, 0, 0, 0, loc)
+	next := func(t time.Time, i int) time.Time { return t.AddDate(0, i, 0) }
+	if weekly {
+		first = weekStart(first)
+		next = func(t time.Time, i int) time.Time { return t.AddDate(0, 0, 7*i) }
+	}
+	var starts []time.Time
+	for t := next(first, -extra); !t.After(now); t = next(t, 1) {
+		starts = append(starts, t)
+	}
+	return starts
+}
+
+// periodChanges returns the changes per repo path recorded in the history by
+// runs of author in each of the periods starting at starts, the last one
+// ending now.
+//
+// Windows of runs often overlap, like of a daily run with -since 168h, so a
+// run only counts with the share of its window not covered by earlier runs,
+// in the period it ran.
+func (h *history) periodChanges(author string, starts []time.Time) (map[string][]float64, error) {
+	type run struct {
+		period int
+		share  float64
+	}
+	runs := make(map[int64]run)
+	// Authors are compared in Go, as -author may have been spelled
+	// differently on each run.
+	rows, err := h.db.Query(`SELECT id, time, since, author FROM runs ORDER BY time`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var covered time.Time
+	for rows.Next() {
+		var id int64
+		var end time.Time
+		var since, runAuthor string
+		if err := rows.Scan(&id, &end, &since, &runAuthor); err != nil {
+			return nil, err
+		}
+		if !sameAuthor(runAuthor, author) {
+			continue
+		}
+		// A last-run window starts where the previous run ended.
+		start := covered
+		if d, err := time.ParseDuration(since); err == nil {
+			start = end.Add(-d)
+		}
+		share := 1.0
+		if end.After(start) {
+			from := start
+			if covered.After(from) {
+				from = covered
+			}
+			share = math.Max(0, float64(end.Sub(from))/float64(end.Sub(start)))
+		}
+		if end.After(covered) {
+			covered = end
+		}
+		period := sort.Search(len(starts), func(i int) bool { return starts[i].After(end) }) - 1
+		if period >= 0 && share > 0 {
+			runs[id] = run{period, share}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string][]float64)
+	rows, err = h.db.Query(`SELECT run_id, path, changes FROM repo_stats`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var path string
+		var n int
+		if err := rows.Scan(&id, &path, &n); err != nil {
+			return nil, err
+		}
+		r, ok := runs[id]
+		if !ok {
+			continue
+		}
+		if changes[path] == nil {
+			changes[path] = make([]float64, len(starts))
+		}
+		changes[path][r.period] += float64(n) * r.share
+	}
+	return changes, rows.Err()
+}
+
+// reportTrend prints changes per repo in each period starting at starts with
+// arrows showing whether they went up or down since the previous period. With
+// rolling over 1 each period shows the average of it and the rolling-1
+// periods before, which are only used for the averages. Only repos at paths
+// are shown, unless there are none.
+func reportTrend(w io.Writer, changes map[string][]float64, starts []time.Time, rolling int, weekly bool, paths []string) {
+	if len(paths) > 0 {
+		only := make(map[string]bool)
+		for _, p := range paths {
+			if abs, err := filepath.Abs(p); err == nil {
+				only[abs] = true
+				only[redact(abs)] = true
+			}
+		}
+		for path := range changes {
+			if !only[path] {
+				delete(changes, path)
+			}
+		}
+	}
+
+	type row struct {
+		path  string
+		total int
+		cells []int
+	}
+	var rows []row
+	for path, perPeriod := range changes {
+		r := row{path: path}
+		for i := rolling - 1; i < len(perPeriod); i++ {
+			var sum float64
+			for _, c := range perPeriod[i-rolling+1 : i+1] {
+				sum += c
+			}
+			n := int(math.Round(sum / float64(rolling)))
+			r.cells = append(r.cells, n)
+			r.total += n
+		}
+		if r.total > 0 {
+			rows = append(rows, r)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].total != rows[j].total {
+			return rows[i].total > rows[j].total
+		}
+		return rows[i].path < rows[j].path
+	})
+
+	t := table{header: []string{"REPO"}, authorsCol: -1}
+	for _, s := range starts[rolling-1:] {
+		if weekly {
+			t.header = append(t.header, s.Format("Jan 2"))
+		} else {
+			t.header = append(t.header, s.Format("2006-01"))
+		}
+	}
+	for _, r := range rows {
+		cells := []string{displayStored(r.path)}
+		for i, n := range r.cells {
+			arrow := ""
+			switch {
+			case i == 0:
+			case n > r.cells[i-1]:
+				arrow = " ↑"
+			case n < r.cells[i-1]:
+				arrow = " ↓"
+			}
+			cells = append(cells, fmt.Sprintf("%d%s", n, arrow))
+		}
+		t.add("", cells...)
+	}
+	t.write(w)
+}
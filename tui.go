@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const tuiHelp = `commands:
+  <n>        show files of repo n      c <n>    show commits of repo n
+  s          toggle sort by changes/path
+  a <name>   only changes by author    a        changes by everyone
+  b          back to repo list         q        quit`
+
+// tui is an interactive terminal UI for exploring the analyzed repos.
+type tui struct {
+	paths  []string
+	opts   options
+	dirs   []directory
+	byPath bool
+	in     *bufio.Scanner
+	out    io.Writer
+}
+
+func runTUI(paths []string, opts options) error {
+	t := &tui{
+		paths: paths,
+		opts:  opts,
+		in:    bufio.NewScanner(os.Stdin),
+		out:   os.Stdout,
+	}
+	t.reload()
+
+	view := func() { t.repos() }
+	for {
+		fmt.Fprint(t.out, "\033[H\033[2J") // clear screen
+		view()
+		fmt.Fprintf(t.out, "\n%s\n> ", tuiHelp)
+		if !t.in.Scan() {
+			fmt.Fprintln(t.out)
+			return t.in.Err()
+		}
+
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(t.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch cmd {
+		case "q":
+			return nil
+		case "b", "":
+			view = func() { t.repos() }
+		case "s":
+			t.byPath = !t.byPath
+			view = func() { t.repos() }
+		case "a":
+			t.opts.author = arg
+			t.reload()
+			view = func() { t.repos() }
+		case "c":
+			if dir, ok := t.dir(arg); ok {
+				view = func() { t.commits(dir) }
+			}
+		default:
+			if dir, ok := t.dir(cmd); ok {
+				view = func() { t.files(dir) }
+			}
+		}
+	}
+}
+
+func (t *tui) reload() {
+	t.dirs = analyze(t.paths, t.opts, nil)
+}
+
+// dir returns the repo numbered n in the repo list.
+func (t *tui) dir(n string) (directory, bool) {
+	i, err := strconv.Atoi(n)
+	if err != nil || i < 1 || i > len(t.dirs) {
+		return directory{}, false
+	}
+	return t.dirs[i-1], true
+}
+
+func (t *tui) repos() {
+	if t.byPath {
+		sort.Slice(t.dirs, func(i, j int) bool { return t.dirs[i].path < t.dirs[j].path })
+	} else {
+		sort.Sort(sort.Reverse(byDirChanges(t.dirs)))
+	}
+
+	who := "everyone"
+	if t.opts.author != "" {
+		who = t.opts.author
+	}
+	fmt.Fprintf(t.out, "changes by %s in the last %v\n\n", who, t.opts.window)
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(t.out)
+	fmt.Fprintf(tw, format, "#", "PATH", "CHANGES", "AUTHORS")
+	for i, dir := range t.dirs {
+		fmt.Fprintf(tw, format, i+1, displayPath(dir.path), dir.changes, strings.Join(dir.authorNames(""), ", "))
+	}
+	tw.Flush()
+}
+
+func (t *tui) files(dir directory) {
+	fmt.Fprintf(t.out, "files in %s\n\n", displayPath(dir.path))
+
+	const format = "%v\t%v\t%v\n"
+	tw := newTableWriter(t.out)
+	fmt.Fprintf(tw, format, "PATH", "CHANGES", "AUTHORS")
+	sort.Sort(sort.Reverse(byFileChanges(dir.files)))
+	for _, f := range dir.files {
+		fmt.Fprintf(tw, format, f.path, f.changes, strings.Join(dir.authorNames(f.path), ", "))
+	}
+	tw.Flush()
+}
+
+func (t *tui) commits(dir directory) {
+	fmt.Fprintf(t.out, "commits in %s\n\n", displayPath(dir.path))
+
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(t.out)
+	fmt.Fprintf(tw, format, "COMMIT", "DATE", "AUTHOR", "CHANGES", "SUBJECT")
+	for _, c := range dir.commits {
+		fmt.Fprintf(tw, format, c.hash[:8], c.when.In(loc).Format("2006-01-02 15:04"), c.author, c.changes, c.subject)
+	}
+	tw.Flush()
+}
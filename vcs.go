@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// vcs is a version control system backend.
+type vcs interface {
+	// head returns the id of the checked out commit.
+	head() (string, error)
+	// pull fetches and merges upstream changes. If HEAD is detached it
+	// only fetches and returns a *detachedError.
+	pull() error
+	// log returns commits selected by opts, with changes filled in and
+	// author set to the name of the author. If lastTip is not empty only
+	// commits that appeared since it was head are returned.
+	log(opts options, lastTip string) ([]commitInfo, error)
+}
+
+// remoteFetcher is a vcs that can fetch all its remotes.
+type remoteFetcher interface {
+	// fetchRemotes fetches all remotes and returns the default branch with
+	// the newest commit among theirs, like upstream/main.
+	fetchRemotes() (string, error)
+}
+
+// shallowRepo is a vcs that can be a shallow clone, missing older history.
+type shallowRepo interface {
+	// shallowStart returns the time of the newest commit whose parents
+	// are missing, or zero time if the repo isn't a shallow clone.
+	shallowStart() (time.Time, error)
+	// deepen fetches older history until it reaches back to since.
+	deepen(since time.Time) error
+}
+
+// openRepo opens the Mercurial or git repo at path. Git repos are read by
+// the -backend.
+func openRepo(path string) (vcs, error) {
+	if fi, err := os.Stat(filepath.Join(path, ".hg")); err == nil && fi.IsDir() {
+		return hgRepo{path: path}, nil
+	}
+	return gitBackends[*backend](path)
+}
+
+// gitBackends open git repos by -backend.
+var gitBackends = map[string]func(path string) (vcs, error){
+	"go-git": func(path string) (vcs, error) { return openGitRepo(path) },
+	"git":    func(path string) (vcs, error) { return openGitCLIRepo(path) },
+}
+
+// span returns the time range of changes selected by opts.
+func (opts options) span() (since, until time.Time) {
+	until = opts.until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	return until.Add(-opts.window), until
+}
+
+// selects tells whether commit c passes the author, message, signature and
+// signoff filters of opts and isn't opted out or, unless asked for by
+// -include-bots, made by a bot. Its changes need not be filled in.
+func (opts options) selects(c commitInfo) bool {
+	switch {
+	case opts.author != "" && !sameAuthor(c.author, opts.author):
+		return false
+	case opts.grep != nil && opts.grep.MatchString(c.message) == opts.invertGrep:
+		return false
+	case opts.optOutMarker != "" && strings.Contains(c.message, opts.optOutMarker):
+		return false
+	case !opts.includeBots && isBot(c):
+		return false
+	case opts.signedOnly && !c.signed:
+		return false
+	case opts.signoffOnly && !c.signoff:
+		return false
+	}
+	return true
+}
+
+// sizes tells whether changes of commit c are within the commit size limits
+// of opts.
+func (opts options) sizes(c commitInfo) bool {
+	return c.changes >= opts.minCommitChanges && (opts.maxCommitChanges <= 0 || c.changes <= opts.maxCommitChanges)
+}
+
+// filtersPaths tells whether opts select only some files.
+func (opts options) filtersPaths() bool {
+	return len(opts.exts) > 0 || len(opts.paths) > 0
+}
+
+// keepsPath tells whether file at path, relative to repo root, passes the
+// extension and path filters of opts and is not ignored.
+func (opts options) keepsPath(path string) bool {
+	path = foldPath(path)
+	if opts.ignore != nil && opts.ignore.Match(strings.Split(path, "/"), false) {
+		return false
+	}
+	if len(opts.exts) > 0 {
+		ext := strings.TrimPrefix(filepath.Ext(path), ".")
+		var ok bool
+		for _, e := range opts.exts {
+			ok = ok || foldPath(e) == ext
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(opts.paths) == 0 {
+		return true
+	}
+	for _, p := range opts.paths {
+		if p = foldPath(p); path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// pathGlobs returns glob patterns selecting the files opts keep, or nil if
+// all files are kept. Directories are matched by dir/**.
+func (opts options) pathGlobs() []string {
+	if !opts.filtersPaths() {
+		return nil
+	}
+	dirs, exts := opts.paths, opts.exts
+	if len(dirs) == 0 {
+		dirs = []string{""}
+	}
+	if len(exts) == 0 {
+		exts = []string{""}
+	}
+	var globs []string
+	for _, d := range dirs {
+		for _, e := range exts {
+			g := "**"
+			if e != "" {
+				g += "/*." + e
+			}
+			if d != "" {
+				g = d + "/" + g
+			}
+			globs = append(globs, g)
+		}
+	}
+	return globs
+}
+
+// parseRepoLogs returns changed files from commits made within opts.window.
+// If lastTip is set only commits that appeared after it are considered. If
+// pulling fails the changes already in the repo are returned along with a
+// *pullError. If HEAD is detached the changes on the fetched default branch
+// are returned along with a *detachedError.
+func parseRepoLogs(repo vcs, opts options, lastTip string) (files []file, commits []commitInfo, err error) {
+	var pullErr error
+	if opts.pull {
+		var detached *detachedError
+		err := repo.pull()
+		switch {
+		case errors.As(err, &detached):
+			if opts.rev == "" {
+				opts.rev = detached.branch
+			}
+			detached.branch, pullErr = opts.rev, detached
+		case err != nil:
+			pullErr = &pullError{Err: err}
+		}
+	}
+
+	if opts.allBranches || opts.rev != "" {
+		// The last run recorded only the tip of HEAD.
+		lastTip = ""
+	}
+	commits, err = repo.log(opts, lastTip)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.filtersPaths() || opts.ignore != nil {
+		// Backends may only narrow down the commits, filter exactly.
+		var kept []commitInfo
+		for _, c := range commits {
+			var files []fileChange
+			c.changes = 0
+			for _, f := range c.files {
+				if opts.keepsPath(f.path) {
+					files = append(files, f)
+					c.changes += f.changes
+				}
+			}
+			if len(files) > 0 {
+				c.files = files
+				kept = append(kept, c)
+			}
+		}
+		commits = kept
+	}
+
+	if opts.allBranches {
+		commits = dropPicked(commits)
+	}
+
+	if opts.cancelReverts {
+		commits = cancelReverts(commits)
+	}
+
+	if opts.generated != "" {
+		var kept []commitInfo
+		for _, c := range commits {
+			c.generated = generatedChurn(c)
+			if !c.generated || opts.generated != "exclude" {
+				kept = append(kept, c)
+			}
+		}
+		commits = kept
+	}
+
+	if opts.minCommitChanges > 0 || opts.maxCommitChanges > 0 {
+		var kept []commitInfo
+		for _, c := range commits {
+			if opts.sizes(c) {
+				kept = append(kept, c)
+			}
+		}
+		commits = kept
+	}
+
+	changesPerFile := make(map[string]int)
+	authorsPerFile := make(map[string][]string)
+	for i, c := range commits {
+		if opts.showEmails {
+			commits[i].author = fmt.Sprintf("%s <%s>", c.author, c.email)
+		}
+		for _, f := range c.files {
+			changesPerFile[f.path] += f.changes
+			authorsPerFile[f.path] = append(authorsPerFile[f.path], commits[i].author)
+		}
+	}
+
+	for f, c := range changesPerFile {
+		files = append(files, file{
+			path:    f,
+			changes: c,
+			authors: uniq(authorsPerFile[f]),
+		})
+	}
+
+	return files, commits, pullErr
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// verifySample is how many commits per repo -verify cross-checks.
+const verifySample = 20
+
+// verifyResults cross-checks changes per file of a sample of commits of each
+// git repo in directories against git log --numstat. It writes mismatches to
+// w and reports whether there were none. Like git, it diffs root commits
+// against the empty tree and shows no changes of merges.
+func verifyResults(w io.Writer, directories []directory, opts options) bool {
+	var checked, mismatches int
+	for _, dir := range directories {
+		if _, ok := dir.repo.(hgRepo); ok {
+			continue
+		}
+		sample := sampleCommits(dir.commits, verifySample)
+		if len(sample) == 0 {
+			continue
+		}
+		// Files ignored by the repo weren't counted by the report either.
+		o := opts
+		var err error
+		if o.ignore, err = ignoreMatcher(dir.path); err != nil {
+			fmt.Fprintf(w, "%s: %v\n", displayPath(dir.path), err)
+			mismatches++
+			continue
+		}
+		args := []string{"log", "--no-walk=unsorted", "--numstat", "--no-renames", "--format=%x1e%H"}
+		for _, c := range sample {
+			args = append(args, c.hash)
+		}
+		out, err := gitCLIRepo{path: dir.path}.git(args...)
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v\n", displayPath(dir.path), err)
+			mismatches++
+			continue
+		}
+		want := make(map[string][]fileChange)
+		for _, rec := range strings.Split(string(out), "\x1e")[1:] {
+			hash, numstat, _ := strings.Cut(rec, "\n")
+			want[hash] = parseNumstat(numstat)
+		}
+		for _, c := range sample {
+			checked++
+			for _, d := range diffChanges(c.files, want[c.hash], o) {
+				fmt.Fprintf(w, "%s %.7s %s\n", displayPath(dir.path), c.hash, d)
+				mismatches++
+			}
+		}
+	}
+	fmt.Fprintf(w, "verified %s against git, %d mismatched\n", plural(checked, "commit"), mismatches)
+	return mismatches == 0
+}
+
+// sampleCommits returns at most n of commits spread evenly over them.
+func sampleCommits(commits []commitInfo, n int) []commitInfo {
+	if len(commits) <= n {
+		return commits
+	}
+	sample := make([]commitInfo, n)
+	for i := range sample {
+		sample[i] = commits[i*len(commits)/n]
+	}
+	return sample
+}
+
+// diffChanges describes files whose changes differ between got and want.
+// Files of want not kept by opts are ignored.
+func diffChanges(got, want []fileChange, opts options) []string {
+	counts := make(map[string][2]int)
+	for _, f := range got {
+		n := counts[f.path]
+		n[0] += f.changes
+		counts[f.path] = n
+	}
+	for _, f := range want {
+		if !opts.keepsPath(f.path) {
+			continue
+		}
+		n := counts[f.path]
+		n[1] += f.changes
+		counts[f.path] = n
+	}
+	var diffs []string
+	for path, n := range counts {
+		if n[0] != n[1] {
+			diffs = append(diffs, fmt.Sprintf("%s: %d changes, git says %d", path, n[0], n[1]))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
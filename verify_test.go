@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestVerifyResultsIgnoredFile(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		ignoreFile: "gen.txt\n",
+		"gen.txt":  "generated\n",
+		"main.txt": "line\nline\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := wt.Commit("Add files", &git.CommitOptions{
+		Author: &object.Signature{Name: "Al", Email: "al@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As analyze counts it, without the ignored gen.txt.
+	directories := []directory{{
+		path: dir,
+		commits: []commitInfo{{
+			repo: dir,
+			hash: hash.String(),
+			files: []fileChange{
+				{path: ignoreFile, changes: 1, net: 1},
+				{path: "main.txt", changes: 2, net: 2},
+			},
+		}},
+	}}
+	var b bytes.Buffer
+	if !verifyResults(&b, directories, options{}) {
+		t.Errorf("verifyResults found mismatches:\n%s", b.String())
+	}
+}
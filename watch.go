@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long watch waits after a change in a repo for more,
+// as a commit or pull writes several files.
+const watchSettle = 200 * time.Millisecond
+
+// watch reports results and re-renders them whenever HEAD of any of the repos
+// at paths moves. Changes are noticed by watching the git or Mercurial
+// directories of the repos rather than by polling. It never returns.
+func watch(paths []string, opts options) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatalf("watching repos: %v", err)
+	}
+	defer w.Close()
+	for _, path := range paths {
+		for _, dir := range watchDirs(path) {
+			if err := w.Add(dir); err != nil {
+				log.Printf("watching %s: %v", dir, err)
+			}
+		}
+	}
+
+	var last map[string]string
+	settled := time.NewTimer(0)
+	for {
+		select {
+		case <-settled.C:
+			heads := repoHeads(paths)
+			if !sameHeads(heads, last) {
+				directories := analyze(paths, opts, nil)
+				fmt.Fprint(os.Stdout, "\033[H\033[2J") // clear screen
+				fmt.Fprintf(os.Stdout, "%s\n\n", time.Now().Format("15:04:05"))
+				reportResults(os.Stdout, directories)
+				last = heads
+			}
+		case ev := <-w.Events:
+			if ev.Op&fsnotify.Create != 0 {
+				// New directories of refs, like refs/heads/feature/.
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					for _, dir := range subdirs(ev.Name) {
+						w.Add(dir)
+					}
+				}
+			}
+			settled.Reset(watchSettle)
+		case err := <-w.Errors:
+			log.Printf("watching repos: %v", err)
+		}
+	}
+}
+
+// watchDirs returns the directories whose files change when HEAD of the repo
+// at path moves: its .hg directory, or its git directory with HEAD and
+// packed-refs and the directories of refs.
+func watchDirs(path string) []string {
+	hg := filepath.Join(path, ".hg")
+	if fi, err := os.Stat(hg); err == nil && fi.IsDir() {
+		return []string{hg}
+	}
+
+	gitDir := filepath.Join(path, ".git")
+	fi, err := os.Stat(gitDir)
+	switch {
+	case err != nil:
+		gitDir = path // bare repo
+	case !fi.IsDir():
+		// Worktree or submodule whose .git file points to its git
+		// directory.
+		data, err := os.ReadFile(gitDir)
+		if err != nil {
+			return nil
+		}
+		dir := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(path, dir)
+		}
+		gitDir = dir
+	}
+	dirs := []string{gitDir}
+
+	// Worktrees share refs with the main repo.
+	common := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		dirs = append(dirs, common)
+	}
+	return append(dirs, subdirs(filepath.Join(common, "refs"))...)
+}
+
+// subdirs returns dir and the directories below it.
+func subdirs(dir string) []string {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// repoHeads returns the HEAD commit hash of each repo at paths.
+func repoHeads(paths []string) map[string]string {
+	heads := make(map[string]string)
+	for _, path := range paths {
+		repo, err := openRepo(path)
+		if err != nil {
+			continue
+		}
+		head, err := repo.head()
+		if err != nil {
+			continue
+		}
+		heads[path] = head
+	}
+	return heads
+}
+
+func sameHeads(a, b map[string]string) bool {
+	if a == nil || b == nil || len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// postWebhook posts the report, or just a summary of it, to a Slack or
+// Mattermost incoming webhook. Both accept the same {"text": ...} payload.
+func postWebhook(url string, directories []directory, summaryOnly bool) error {
+	var text string
+	if summaryOnly {
+		text = summary(directories)
+	} else {
+		var buf bytes.Buffer
+		reportResults(&buf, directories)
+		text = summary(directories) + "\n```\n" + buf.String() + "```"
+	}
+
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// summary returns a one-line summary of directories.
+func summary(directories []directory) string {
+	if len(directories) == 0 {
+		return "No changes."
+	}
+
+	var total int
+	for _, dir := range directories {
+		total += dir.changes
+	}
+	sorted := append([]directory(nil), directories...)
+	sort.Sort(sort.Reverse(byDirChanges(sorted)))
+	busiest := sorted[0]
+	return fmt.Sprintf("%d changes in %d repos, most in %s (%d).",
+		total, len(directories), displayPath(busiest.path), busiest.changes)
+}
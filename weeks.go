@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// weekStart returns the start of the ISO week, which starts on Monday, that
+// t falls into.
+func weekStart(t time.Time) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// splitByWeek returns directories with only commits of each week, by the
+// start of the week. Directories without commits in a week are left out.
+func splitByWeek(directories []directory) map[time.Time][]directory {
+	weeks := make(map[time.Time][]directory)
+	for _, dir := range directories {
+		perWeek := make(map[time.Time][]commitInfo)
+		for _, c := range dir.commits {
+			w := weekStart(c.when)
+			perWeek[w] = append(perWeek[w], c)
+		}
+		for w, commits := range perWeek {
+			d := dir
+			d.commits = commits
+			d.recount()
+			weeks[w] = append(weeks[w], d)
+		}
+	}
+	return weeks
+}
+
+// reportWeeks prints a report of changes for each week, oldest first.
+func reportWeeks(w io.Writer, directories []directory) {
+	weeks := splitByWeek(directories)
+	var starts []time.Time
+	for s := range weeks {
+		starts = append(starts, s)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	for i, s := range starts {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		_, week := s.ISOWeek()
+		fmt.Fprintf(w, "Week %d, %s - %s\n\n", week, s.Format("Jan 2"), s.AddDate(0, 0, 6).Format("Jan 2 2006"))
+		reportResults(w, weeks[s])
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// weights map file patterns to how much their changes count in the score.
+// The first pattern matching a file wins, so they are kept in the order of
+// the config file.
+type weights []weight
+
+type weight struct {
+	pattern string
+	value   float64
+}
+
+func (w *weights) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: weights: want a mapping of patterns to weights", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var wt weight
+		if err := n.Content[i].Decode(&wt.pattern); err != nil {
+			return err
+		}
+		wt.pattern = foldPath(slashPath(wt.pattern))
+		if _, err := path.Match(wt.pattern, ""); err != nil {
+			return fmt.Errorf("line %d: weights: %q: %v", n.Content[i].Line, wt.pattern, err)
+		}
+		if err := n.Content[i+1].Decode(&wt.value); err != nil {
+			return err
+		}
+		*w = append(*w, wt)
+	}
+	return nil
+}
+
+// of returns the weight of the file at path in a repo. Patterns with a slash
+// match the whole path, others only the file name. Unmatched files weigh 1.
+func (w weights) of(file string) float64 {
+	file = foldPath(slashPath(file))
+	for _, wt := range w {
+		name := file
+		if !strings.Contains(wt.pattern, "/") {
+			name = path.Base(file)
+		}
+		if ok, _ := path.Match(wt.pattern, name); ok {
+			return wt.value
+		}
+	}
+	return 1
+}
+
+// score returns changes in dir multiplied by the weights of their files in
+// the repo and config. If file is not empty only changes of that file are
+// scored.
+func (dir directory) score(file string) float64 {
+	w := repoWeights(dir.path)
+	var score float64
+	for _, c := range dir.commits {
+		for _, f := range c.files {
+			if file == "" || f.path == file {
+				score += float64(f.changes) * w.of(f.path)
+			}
+		}
+	}
+	return score
+}
+
+// scored tells whether the score of directories is shown, which it is if
+// any weights are set.
+func scored(directories []directory) bool {
+	for _, dir := range directories {
+		if len(repoWeights(dir.path)) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopWords are left out of subject keywords.
+var stopWords = map[string]bool{
+	"about": true, "after": true, "also": true, "from": true, "into": true,
+	"make": true, "more": true, "only": true, "some": true, "than": true,
+	"that": true, "them": true, "then": true, "this": true, "when": true,
+	"with": true, "without": true,
+	// Subjects of merge commits.
+	"merge": true, "branch": true, "remote-tracking": true, "pull": true,
+	"request": true,
+	// Markers of commits to be squashed and references to tickets.
+	"fixup": true, "squash": true, "refs": true,
+}
+
+// why returns qualitative context of changes in dir, or of the file at path
+// if it's not empty, as set by -why: the subject of the largest commit or the
+// two most frequent keywords of commit subjects.
+func (dir directory) why(mode, path string) string {
+	switch mode {
+	case "largest":
+		var subject string
+		var max int
+		for _, c := range dir.commits {
+			if c.generated {
+				// Not work, though the largest.
+				continue
+			}
+			n := c.changes
+			if path != "" {
+				n = 0
+				for _, f := range c.files {
+					if f.path == path {
+						n += f.changes
+					}
+				}
+			}
+			if n > max {
+				subject, max = c.subject, n
+			}
+		}
+		return subject
+	case "keywords":
+		var subjects []string
+		for _, c := range dir.commits {
+			if path == "" || c.touches(path) {
+				subjects = append(subjects, c.subject)
+			}
+		}
+		var words []string
+		for _, k := range subjectKeywords(subjects) {
+			if len(words) == 2 {
+				break
+			}
+			words = append(words, k.word)
+		}
+		return strings.Join(words, ", ")
+	}
+	return ""
+}
+
+// keyword is a word of commit subjects and how many times it's used.
+type keyword struct {
+	word  string
+	count int
+}
+
+// subjectKeywords returns words of subjects at least four letters long,
+// except stop words and conventional commit prefixes like "feat(api):", the
+// most frequent first.
+func subjectKeywords(subjects []string) []keyword {
+	count := make(map[string]int)
+	for _, s := range subjects {
+		s = conventionalCommit.ReplaceAllString(s, "")
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+		}) {
+			if len(w) >= 4 && !stopWords[w] {
+				count[w]++
+			}
+		}
+	}
+	var keywords []keyword
+	for w, n := range count {
+		keywords = append(keywords, keyword{w, n})
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].count != keywords[j].count {
+			return keywords[i].count > keywords[j].count
+		}
+		return keywords[i].word < keywords[j].word
+	})
+	return keywords
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// runeWidth returns how many terminal columns r takes: two for wide East
+// Asian characters, none for combining marks and other zero-width ones.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) || unicode.IsControl(r):
+		return 0
+	case r >= 0x1160 && r <= 0x11ff:
+		// Hangul medial vowels and final consonants join the syllable
+		// before them.
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns how many terminal columns s takes.
+func displayWidth(s string) int {
+	var n int
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// widthOffset returns the byte offset in s where its first n columns end, or
+// -1 if s is narrower.
+func widthOffset(s string, n int) int {
+	var w int
+	for offset, r := range s {
+		if w >= n && runeWidth(r) > 0 {
+			return offset
+		}
+		w += runeWidth(r)
+	}
+	if w >= n {
+		return len(s)
+	}
+	return -1
+}
+
+// tableWriter aligns tab-terminated cells into columns like a tabwriter.Writer
+// padding with spaces, minimum width 0 and padding 2 does, but measures cells
+// by display width, so CJK and combining characters don't break alignment.
+// Nothing is written until Flush.
+type tableWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func newTableWriter(w io.Writer) *tableWriter {
+	return &tableWriter{w: w}
+}
+
+func (t *tableWriter) Write(p []byte) (int, error) {
+	return t.buf.Write(p)
+}
+
+// Flush writes the aligned text and empties the buffer.
+func (t *tableWriter) Flush() error {
+	const padding = 2
+	text := t.buf.String()
+	t.buf.Reset()
+	if text == "" {
+		return nil
+	}
+	var lines [][]string
+	for _, l := range strings.SplitAfter(text, "\n") {
+		if l != "" {
+			lines = append(lines, strings.Split(strings.TrimSuffix(l, "\n"), "\t"))
+		}
+	}
+	endsLine := strings.HasSuffix(text, "\n")
+
+	var out strings.Builder
+	writeLines := func(widths []int, from, to int) {
+		for i := from; i < to; i++ {
+			for j, cell := range lines[i] {
+				out.WriteString(cell)
+				if j < len(widths) && j < len(lines[i])-1 {
+					out.WriteString(strings.Repeat(" ", widths[j]-displayWidth(cell)))
+				}
+			}
+			if i+1 < len(lines) || endsLine {
+				out.WriteByte('\n')
+			}
+		}
+	}
+	// format aligns column len(widths) in blocks of consecutive lines of
+	// lines[from:to] that have a tab-terminated cell in it, as tabwriter
+	// does, and the columns after it within each block.
+	var format func(widths []int, from, to int)
+	format = func(widths []int, from, to int) {
+		column := len(widths)
+		for this := from; this < to; this++ {
+			if column >= len(lines[this])-1 {
+				continue
+			}
+			writeLines(widths, from, this)
+			from = this
+			width := 0
+			for ; this < to && column < len(lines[this])-1; this++ {
+				if w := displayWidth(lines[this][column]) + padding; w > width {
+					width = w
+				}
+			}
+			format(append(widths, width), from, this)
+			from = this
+			this--
+		}
+		writeLines(widths, from, to)
+	}
+	format(nil, 0, len(lines))
+	_, err := io.WriteString(t.w, out.String())
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// workHours is a daily span of working time, in minutes since midnight.
+type workHours struct {
+	start, end int
+}
+
+func parseWorkHours(s string) (workHours, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return workHours{}, fmt.Errorf("want HH:MM-HH:MM, got %q", s)
+	}
+	var wh workHours
+	for _, x := range []struct {
+		s string
+		m *int
+	}{{from, &wh.start}, {to, &wh.end}} {
+		t, err := time.Parse("15:04", strings.TrimSpace(x.s))
+		if err != nil {
+			return workHours{}, fmt.Errorf("want HH:MM-HH:MM, got %q", s)
+		}
+		*x.m = t.Hour()*60 + t.Minute()
+	}
+	return wh, nil
+}
+
+// contains reports whether t falls within working hours. Spans crossing
+// midnight, like 22:00-06:00, are supported.
+func (wh workHours) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if wh.start <= wh.end {
+		return m >= wh.start && m < wh.end
+	}
+	return m >= wh.start || m < wh.end
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// reportWorkHours prints, per author, the share of commits made outside
+// working hours on weekdays and on weekends. Times are taken in the author's
+// commit timezone unless -tz is set.
+func reportWorkHours(w io.Writer, directories []directory, wh workHours) {
+	type counts struct{ commits, afterHours, weekend int }
+	perAuthor := make(map[string]*counts)
+	for _, dir := range directories {
+		for _, c := range dir.commits {
+			n, ok := perAuthor[c.author]
+			if !ok {
+				n = &counts{}
+				perAuthor[c.author] = n
+			}
+			n.commits++
+			t := c.when
+			if *tz != "" {
+				t = t.In(loc)
+			}
+			switch {
+			case isWeekend(t):
+				n.weekend++
+			case !wh.contains(t):
+				n.afterHours++
+			}
+		}
+	}
+	if len(perAuthor) == 0 {
+		return
+	}
+
+	var authors []string
+	for a := range perAuthor {
+		authors = append(authors, a)
+	}
+	sort.Strings(authors)
+
+	const format = "%v\t%v\t%v\t%v\n"
+	tw := newTableWriter(w)
+	fmt.Fprintf(tw, format, "AUTHOR", "COMMITS", "AFTER HOURS", "WEEKEND")
+	for _, a := range authors {
+		n := perAuthor[a]
+		fmt.Fprintf(tw, format, a, n.commits, percent(n.afterHours, n.commits), percent(n.weekend, n.commits))
+	}
+	tw.Flush()
+}